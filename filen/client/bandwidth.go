@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"golang.org/x/time/rate"
+	"io"
+	"sync"
+	"time"
+)
+
+// BandwidthLimits caps the throughput of file chunk transfers in bytes per second.
+// The limits are shared by all concurrent transfers of a client. Zero means unlimited.
+type BandwidthLimits struct {
+	Ingest int64 // uploads to the ingest hosts
+	Egest  int64 // downloads from the egest hosts
+}
+
+// A BandwidthRule applies different limits during a time of day, in the local time zone.
+type BandwidthRule struct {
+	From   time.Duration // start of the period, as the time since midnight
+	To     time.Duration // end of the period (exclusive), may be less than From to wrap around midnight
+	Limits BandwidthLimits
+}
+
+// contains reports whether the rule applies at the given time.
+func (r BandwidthRule) contains(t time.Time) bool {
+	hour, minute, second := t.Clock()
+	sinceMidnight := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute +
+		time.Duration(second)*time.Second + time.Duration(t.Nanosecond())
+	if r.From <= r.To {
+		return sinceMidnight >= r.From && sinceMidnight < r.To
+	}
+	return sinceMidnight >= r.From || sinceMidnight < r.To
+}
+
+// bandwidthBurst is the maximum number of bytes transferred at once without waiting.
+// Transfers are limited in pieces of this size, which keeps concurrent transfers smooth.
+const bandwidthBurst = 64 * 1024
+
+// bandwidthLimiter holds a token bucket for each direction. It is shared by all copies of a client.
+type bandwidthLimiter struct {
+	mu       sync.Mutex
+	limits   BandwidthLimits // the limits outside the schedule
+	schedule []BandwidthRule
+	active   BandwidthLimits // the limits the buckets are currently set to
+	ingest   *rate.Limiter
+	egest    *rate.Limiter
+	now      func() time.Time
+}
+
+func newBandwidthLimiter(limits BandwidthLimits, schedule []BandwidthRule) *bandwidthLimiter {
+	l := &bandwidthLimiter{
+		ingest: rate.NewLimiter(rate.Inf, bandwidthBurst),
+		egest:  rate.NewLimiter(rate.Inf, bandwidthBurst),
+		now:    time.Now,
+	}
+	l.limits = limits
+	l.schedule = append([]BandwidthRule(nil), schedule...)
+	l.update()
+	return l
+}
+
+func toRate(bytesPerSecond int64) rate.Limit {
+	if bytesPerSecond <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(bytesPerSecond)
+}
+
+// update applies the limits in effect now to the buckets. l.mu must be held.
+func (l *bandwidthLimiter) update() BandwidthLimits {
+	limits := l.limits
+	now := l.now()
+	for _, rule := range l.schedule {
+		if rule.contains(now) {
+			limits = rule.Limits
+			break
+		}
+	}
+	if limits != l.active {
+		l.active = limits
+		l.ingest.SetLimit(toRate(limits.Ingest))
+		l.egest.SetLimit(toRate(limits.Egest))
+	}
+	return limits
+}
+
+// current returns the limits in effect now.
+func (l *bandwidthLimiter) current() BandwidthLimits {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.update()
+}
+
+// wait blocks until n bytes may be transferred in the given direction. n must not exceed bandwidthBurst.
+func (l *bandwidthLimiter) wait(ctx context.Context, urlType int, n int) error {
+	l.mu.Lock()
+	l.update()
+	l.mu.Unlock()
+	limiter := l.egest
+	if urlType == URLTypeIngest {
+		limiter = l.ingest
+	}
+	return limiter.WaitN(ctx, n)
+}
+
+// limitedReader reads from r no faster than the limiter allows.
+type limitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *bandwidthLimiter
+	urlType int
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthBurst {
+		p = p[:bandwidthBurst]
+	}
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		if waitErr := lr.limiter.wait(lr.ctx, lr.urlType, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// limitReader returns a reader which limits the bandwidth of reading r, for a transfer to or from a host of urlType.
+func (uc *UnauthorizedClient) limitReader(ctx context.Context, r io.Reader, urlType int) io.Reader {
+	return &limitedReader{ctx: ctx, r: r, limiter: uc.bandwidth, urlType: urlType}
+}
+
+// SetBandwidthLimits changes the bandwidth limits while the client is in use.
+// It applies to transfers in progress and to all clients derived from this one, e.g. by [UnauthorizedClient.Authorize].
+func (uc *UnauthorizedClient) SetBandwidthLimits(limits BandwidthLimits) {
+	l := uc.bandwidth
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+	l.update()
+}
+
+// SetBandwidthSchedule sets rules which override the bandwidth limits during certain times of day.
+// The first rule containing the current time applies, otherwise the limits set by [UnauthorizedClient.SetBandwidthLimits].
+func (uc *UnauthorizedClient) SetBandwidthSchedule(schedule []BandwidthRule) {
+	l := uc.bandwidth
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.schedule = append([]BandwidthRule(nil), schedule...)
+	l.update()
+}
+
+// BandwidthLimits returns the bandwidth limits in effect now, taking the schedule into account.
+func (uc *UnauthorizedClient) BandwidthLimits() BandwidthLimits {
+	return uc.bandwidth.current()
+}
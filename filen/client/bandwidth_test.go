@@ -0,0 +1,93 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBandwidthSchedule(t *testing.T) {
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	now := day
+	l := newBandwidthLimiter(BandwidthLimits{Ingest: 100, Egest: 200}, []BandwidthRule{
+		{From: 9 * time.Hour, To: 17 * time.Hour, Limits: BandwidthLimits{Ingest: 10, Egest: 20}},
+		{From: 22 * time.Hour, To: 6 * time.Hour, Limits: BandwidthLimits{}},
+	})
+	l.now = func() time.Time { return now }
+
+	tests := []struct {
+		at       time.Duration
+		expected BandwidthLimits
+	}{
+		{8*time.Hour + 59*time.Minute, BandwidthLimits{Ingest: 100, Egest: 200}},
+		{9 * time.Hour, BandwidthLimits{Ingest: 10, Egest: 20}},
+		{16*time.Hour + 59*time.Minute, BandwidthLimits{Ingest: 10, Egest: 20}},
+		{17 * time.Hour, BandwidthLimits{Ingest: 100, Egest: 200}},
+		{23 * time.Hour, BandwidthLimits{}},
+		{5 * time.Hour, BandwidthLimits{}},
+		{6 * time.Hour, BandwidthLimits{Ingest: 100, Egest: 200}},
+	}
+	for _, test := range tests {
+		now = day.Add(test.at)
+		if limits := l.current(); limits != test.expected {
+			t.Errorf("at %s: expected %v, got %v", test.at, test.expected, limits)
+		}
+	}
+	if l.ingest.Limit() != toRate(100) || l.egest.Limit() != toRate(200) {
+		t.Errorf("buckets were not updated: %v, %v", l.ingest.Limit(), l.egest.Limit())
+	}
+}
+
+func TestBandwidthLimits(t *testing.T) {
+	chunk := make([]byte, 3*bandwidthBurst)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			_, _ = io.Copy(io.Discard, r.Body)
+			_, _ = w.Write([]byte(`{"status":true,"message":"","code":"","data":{"bucket":"b","region":"r"}}`))
+			return
+		}
+		_, _ = w.Write(chunk)
+	}))
+	defer server.Close()
+
+	// the first burst passes immediately, the remaining two take half a second each
+	limit := int64(4 * bandwidthBurst)
+	c := NewWithOptions(context.Background(), Options{
+		Endpoints: SingleHostEndpoints(server.URL),
+		Bandwidth: BandwidthLimits{Ingest: limit, Egest: limit},
+	}).Authorize("key")
+
+	start := time.Now()
+	data, err := c.DownloadFileChunk(context.Background(), "uuid", "r", "b", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, chunk) {
+		t.Fatal("downloaded data did not match")
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("download was not limited, took %s", elapsed)
+	}
+
+	start = time.Now()
+	if _, err = c.PostV3Upload(context.Background(), "uuid", 0, "parent", "key", chunk); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("upload was not limited, took %s", elapsed)
+	}
+
+	// lifting the limit applies to the same client
+	c.SetBandwidthLimits(BandwidthLimits{})
+	start = time.Now()
+	if _, err = c.DownloadFileChunk(context.Background(), "uuid", "r", "b", 0); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Fatalf("download was still limited, took %s", elapsed)
+	}
+}
@@ -16,7 +16,12 @@ import (
 )
 
 type UnauthorizedClient struct {
-	httpClient http.Client // cached request client
+	httpClient  http.Client // cached request client
+	baseURLs    baseURLs    // hosts to send requests to
+	userAgent   string      // User-Agent header, or empty for the default
+	header      http.Header // headers added to every request
+	retryPolicy RetryPolicy // how failed requests are retried
+	bandwidth   *bandwidthLimiter
 }
 
 type Client struct {
@@ -24,9 +29,35 @@ type Client struct {
 	APIKey string // the Filen API key
 }
 
+// Options configures a client created with [NewWithOptions]. The zero value is equivalent to [New].
+type Options struct {
+	Endpoints Endpoints         // the hosts to send requests to
+	Transport http.RoundTripper // the transport for all requests, or nil for rclone's default transport
+	UserAgent string            // overrides the User-Agent header if set
+	Header    http.Header       // headers added to every request
+	Retry     RetryPolicy       // how failed requests are retried, the zero value means DefaultRetryPolicy
+	Bandwidth BandwidthLimits   // limits the throughput of file chunk transfers
+	// BandwidthSchedule overrides Bandwidth during certain times of day, see [UnauthorizedClient.SetBandwidthSchedule].
+	BandwidthSchedule []BandwidthRule
+}
+
 func New(ctx context.Context) *UnauthorizedClient {
+	return NewWithOptions(ctx, Options{})
+}
+
+// NewWithOptions creates a client configured by opts.
+func NewWithOptions(ctx context.Context, opts Options) *UnauthorizedClient {
+	httpClient := fshttp.NewClient(ctx)
+	if opts.Transport != nil {
+		httpClient.Transport = opts.Transport
+	}
 	return &UnauthorizedClient{
-		httpClient: *fshttp.NewClient(ctx),
+		httpClient:  *httpClient,
+		baseURLs:    opts.Endpoints.baseURLs(),
+		userAgent:   opts.UserAgent,
+		header:      opts.Header.Clone(),
+		retryPolicy: opts.Retry,
+		bandwidth:   newBandwidthLimiter(opts.Bandwidth, opts.BandwidthSchedule),
 	}
 }
 
@@ -38,10 +69,7 @@ func (uc *UnauthorizedClient) Authorize(apiKey string) *Client {
 }
 
 func NewWithAPIKey(ctx context.Context, apiKey string) *Client {
-	return &Client{
-		UnauthorizedClient: *New(ctx),
-		APIKey:             apiKey,
-	}
+	return NewWithOptions(ctx, Options{}).Authorize(apiKey)
 }
 
 // A RequestError carries information on a failed HTTP request.
@@ -68,6 +96,10 @@ func (e *RequestError) Error() string {
 	return builder.String()
 }
 
+func (e *RequestError) Unwrap() error {
+	return e.UnderlyingError
+}
+
 // cannotSendError returns a RequestError from an error that occurred while sending an HTTP request.
 func cannotSendError(method string, url *FilenURL, err error) error {
 	return &RequestError{
@@ -79,10 +111,18 @@ func cannotSendError(method string, url *FilenURL, err error) error {
 }
 
 func (uc *UnauthorizedClient) buildReaderRequest(ctx context.Context, method string, url *FilenURL, data io.Reader) (*http.Request, error) {
-	req, err := http.NewRequestWithContext(ctx, method, url.String(), data)
+	req, err := http.NewRequestWithContext(ctx, method, url.resolve(uc.baseURLs), data)
 	if err != nil {
 		return nil, &RequestError{"Cannot build requestData", method, url, err}
 	}
+	for key, values := range uc.header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if uc.userAgent != "" {
+		req.Header.Set("User-Agent", uc.userAgent)
+	}
 	return req, nil
 }
 
@@ -135,6 +175,7 @@ func parseResponse(method string, url *FilenURL, response *http.Response) (*aPIR
 	if err != nil {
 		return nil, &RequestError{fmt.Sprintf("Cannot unmarshal response %s", string(resBody)), method, url, nil}
 	}
+	apiResponse.httpStatus = response.StatusCode
 	return &apiResponse, nil
 }
 
@@ -142,15 +183,19 @@ func parseResponse(method string, url *FilenURL, response *http.Response) (*aPIR
 // It takes a http.Request object, the associated http.Client, and the method and path
 // as parameters. It returns an aPIResponse containing the parsed response data, or a
 // RequestError if the request fails or the response cannot be parsed.
+// Errors which are worth retrying are marked as such.
 func handleRequest(request *http.Request, httpClient *http.Client, method string, url *FilenURL) (*aPIResponse, error) {
 	//startTime := time.Now()
 	res, err := httpClient.Do(request)
 	if err != nil {
-		return nil, cannotSendError(method, url, err)
+		return nil, sendError(request.Context(), method, url, err)
 	}
 	defer func() {
 		_ = res.Body.Close()
 	}()
+	if err = statusError(method, url, res); err != nil {
+		return nil, err
+	}
 
 	apiRes, err := parseResponse(method, url, res)
 	if err != nil {
@@ -158,7 +203,7 @@ func handleRequest(request *http.Request, httpClient *http.Client, method string
 	}
 	err = apiRes.CheckError()
 	if err != nil {
-		return nil, err
+		return nil, &RequestError{"Response error", method, url, err}
 	}
 	//fmt.Printf("Request %s %s took %s\n", method, url, time.Since(startTime))
 	return apiRes, nil
@@ -180,11 +225,16 @@ func convertIntoResponseData(method string, url *FilenURL, response *aPIResponse
 }
 
 func (uc *UnauthorizedClient) Request(ctx context.Context, method string, url *FilenURL, requestData any) (*aPIResponse, error) {
-	request, err := uc.buildJSONRequest(ctx, method, url, requestData)
-	if err != nil {
-		return nil, err
-	}
-	return handleRequest(request, &uc.httpClient, method, url)
+	var response *aPIResponse
+	err := uc.withRetries(ctx, method, url, func() error {
+		request, err := uc.buildJSONRequest(ctx, method, url, requestData)
+		if err != nil {
+			return err
+		}
+		response, err = handleRequest(request, &uc.httpClient, method, url)
+		return err
+	})
+	return response, err
 }
 
 func (uc *UnauthorizedClient) RequestData(ctx context.Context, method string, url *FilenURL, requestData any, outData any) (*aPIResponse, error) {
@@ -200,11 +250,16 @@ func (uc *UnauthorizedClient) RequestData(ctx context.Context, method string, ur
 }
 
 func (c *Client) Request(ctx context.Context, method string, url *FilenURL, requestData any) (*aPIResponse, error) {
-	request, err := c.buildJSONRequest(ctx, method, url, requestData)
-	if err != nil {
-		return nil, err
-	}
-	return handleRequest(request, &c.httpClient, method, url)
+	var response *aPIResponse
+	err := c.withRetries(ctx, method, url, func() error {
+		request, err := c.buildJSONRequest(ctx, method, url, requestData)
+		if err != nil {
+			return err
+		}
+		response, err = handleRequest(request, &c.httpClient, method, url)
+		return err
+	})
+	return response, err
 }
 
 func (c *Client) RequestData(ctx context.Context, method string, url *FilenURL, requestData any, outData any) (*aPIResponse, error) {
@@ -227,11 +282,18 @@ type aPIResponse struct {
 	Message string          `json:"message"` // additional information
 	Code    string          `json:"code"`    // a status code
 	Data    json.RawMessage `json:"data"`    // response body, or nil
+
+	httpStatus int // the HTTP status code of the response
 }
 
+// CheckError returns an *APIError if the response reports a failure.
 func (res *aPIResponse) CheckError() error {
 	if !res.Status {
-		return fmt.Errorf("response error: %s %s", res.Message, res.Code)
+		return &APIError{
+			HTTPStatus: res.httpStatus,
+			Code:       res.Code,
+			Message:    res.Message,
+		}
 	}
 	return nil
 }
@@ -264,6 +326,20 @@ func (c *Client) DownloadFileChunk(ctx context.Context, uuid string, region stri
 		Path: fmt.Sprintf("/%s/%s/%s/%v", region, bucket, uuid, chunkIdx),
 	}
 
+	var data []byte
+	err := c.withRetries(ctx, "GET", url, func() error {
+		var err error
+		data, err = c.downloadFileChunk(ctx, url)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// downloadFileChunk makes a single attempt at downloading a file chunk.
+func (c *Client) downloadFileChunk(ctx context.Context, url *FilenURL) ([]byte, error) {
 	// Can't use the standard Client.RequestData because the response body is raw bytes
 	request, err := c.buildJSONRequest(ctx, "GET", url, nil)
 	if err != nil {
@@ -271,14 +347,25 @@ func (c *Client) DownloadFileChunk(ctx context.Context, uuid string, region stri
 	}
 	res, err := c.httpClient.Do(request)
 	if err != nil {
-		return nil, cannotSendError("GET", url, err)
+		return nil, sendError(ctx, "GET", url, err)
 	}
 	defer func() {
 		_ = res.Body.Close()
 	}()
-	data, err := io.ReadAll(res.Body)
-	if err != nil {
+	if err = statusError("GET", url, res); err != nil {
 		return nil, err
 	}
+	if res.StatusCode != http.StatusOK {
+		// errors are reported in the usual JSON format
+		apiRes, err := parseResponse("GET", url, res)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &RequestError{fmt.Sprintf("Server responded with %s", res.Status), "GET", url, apiRes.CheckError()}
+	}
+	data, err := io.ReadAll(c.limitReader(ctx, res.Body, url.Type))
+	if err != nil {
+		return nil, sendError(ctx, "GET", url, err)
+	}
 	return data, nil
 }
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingTransport struct {
+	count int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.count++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNewWithOptions(t *testing.T) {
+	var gotRequest *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRequest = r
+		_, _ = w.Write([]byte(`{"status":true,"message":"","code":"","data":{"authVersion":3,"salt":"abc"}}`))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	uc := NewWithOptions(context.Background(), Options{
+		Endpoints: Endpoints{Gateway: []string{server.URL + "/"}},
+		Transport: transport,
+		UserAgent: "filen-sdk-go-test",
+		Header:    http.Header{"X-Test": []string{"value"}},
+	})
+	authInfo, err := uc.PostV3AuthInfo(context.Background(), "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authInfo.AuthVersion != 3 || authInfo.Salt != "abc" {
+		t.Fatalf("unexpected auth info %#v", authInfo)
+	}
+	if transport.count != 1 {
+		t.Fatalf("expected the custom transport to be used once, was used %d times", transport.count)
+	}
+	if gotRequest.URL.Path != "/v3/auth/info" {
+		t.Fatalf("expected path /v3/auth/info, got %s", gotRequest.URL.Path)
+	}
+	if ua := gotRequest.Header.Get("User-Agent"); ua != "filen-sdk-go-test" {
+		t.Fatalf("expected custom user agent, got %s", ua)
+	}
+	if h := gotRequest.Header.Get("X-Test"); h != "value" {
+		t.Fatalf("expected custom header, got %s", h)
+	}
+
+	// unset endpoints fall back to the Filen hosts
+	url := &FilenURL{Type: URLTypeEgest, Path: "/a"}
+	resolved := url.resolve(uc.baseURLs)
+	if host, ok := strings.CutSuffix(resolved, "/a"); !ok || host != egestURLs[url.host] {
+		t.Fatalf("expected default egest host, got %s", resolved)
+	}
+}
+
+func TestRetryFailover(t *testing.T) {
+	var failing atomic.Int32
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failing.Add(1)
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failingServer.Close()
+	workingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":true,"message":"","code":"","data":{"uuid":"abc"}}`))
+	}))
+	defer workingServer.Close()
+
+	c := NewWithOptions(context.Background(), Options{
+		Endpoints: Endpoints{Gateway: []string{failingServer.URL, workingServer.URL}},
+		Retry:     RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	}).Authorize("key")
+	for i := 0; i < 5; i++ {
+		response, err := c.GetV3UserBaseFolder(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.UUID != "abc" {
+			t.Fatalf("unexpected response %#v", response)
+		}
+	}
+	if failing.Load() > 5 {
+		t.Fatalf("expected the failing host to be tried at most once per request, was tried %d times", failing.Load())
+	}
+
+	// without another host, the request fails after all attempts
+	c = NewWithOptions(context.Background(), Options{
+		Endpoints: Endpoints{Gateway: []string{failingServer.URL}},
+		Retry:     RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	}).Authorize("key")
+	failing.Store(0)
+	if _, err := c.GetV3UserBaseFolder(context.Background()); err == nil {
+		t.Fatal("expected request to fail")
+	}
+	if failing.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", failing.Load())
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	var failing atomic.Int32
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failing.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingServer.Close()
+	workingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":true,"message":"","code":"","data":null}`))
+	}))
+	defer workingServer.Close()
+	// a closed server refuses connections, so requests to it are known not to have been sent
+	closedServer := httptest.NewServer(http.NotFoundHandler())
+	closedServer.Close()
+	retry := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+	c := NewWithOptions(context.Background(), Options{
+		Endpoints: Endpoints{Gateway: []string{closedServer.URL, workingServer.URL}},
+		Retry:     retry,
+	}).Authorize("key")
+	for i := 0; i < 5; i++ {
+		if err := c.PostV3FileMove(context.Background(), "file", "dir"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a server error may come after the move was applied
+	c = NewWithOptions(context.Background(), Options{
+		Endpoints: Endpoints{Gateway: []string{failingServer.URL}},
+		Retry:     retry,
+	}).Authorize("key")
+	if err := c.PostV3FileMove(context.Background(), "file", "dir"); err == nil {
+		t.Fatal("expected request to fail")
+	}
+	if failing.Load() != 1 {
+		t.Fatalf("expected 1 attempt, got %d", failing.Load())
+	}
+	failing.Store(0)
+	if _, err := c.PostV3File(context.Background(), "file"); err == nil {
+		t.Fatal("expected request to fail")
+	}
+	if failing.Load() != 3 {
+		t.Fatalf("expected idempotent request to be attempted 3 times, got %d", failing.Load())
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for retry := 1; retry < 10; retry++ {
+		delay := policy.delay(retry, 0)
+		if delay <= 0 || delay > policy.MaxDelay {
+			t.Fatalf("retry %d: delay %s out of bounds", retry, delay)
+		}
+	}
+	if delay := policy.delay(1, 500*time.Millisecond); delay != 500*time.Millisecond {
+		t.Fatalf("expected Retry-After to be honoured, got %s", delay)
+	}
+	if delay := policy.delay(1, time.Hour); delay != policy.MaxDelay {
+		t.Fatalf("expected Retry-After to be capped, got %s", delay)
+	}
+}
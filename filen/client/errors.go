@@ -0,0 +1,66 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Sentinel errors matching classes of [APIError], for use with errors.Is.
+var (
+	ErrNotFound           = errors.New("not found")                // the file, directory or other resource does not exist
+	ErrUnauthorized       = errors.New("unauthorized")             // the API key is missing, invalid or expired
+	ErrWrongCredentials   = errors.New("wrong email or password")  // login failed
+	ErrTwoFactorRequired  = errors.New("two-factor code required") // login requires a two-factor code
+	ErrWrongTwoFactorCode = errors.New("wrong two-factor code")    // login failed because of the two-factor code
+	ErrQuotaExceeded      = errors.New("storage quota exceeded")   // the account's storage is full
+	ErrRateLimited        = errors.New("rate limited")             // too many requests were sent
+)
+
+// apiErrorCodes maps Filen error codes to sentinel errors.
+var apiErrorCodes = map[string]error{
+	"file_not_found":          ErrNotFound,
+	"folder_not_found":        ErrNotFound,
+	"parent_not_found":        ErrNotFound,
+	"not_found":               ErrNotFound,
+	"upload_not_found":        ErrNotFound,
+	"chunk_not_found":         ErrNotFound,
+	"api_key_not_found":       ErrUnauthorized,
+	"unauthorized":            ErrUnauthorized,
+	"email_or_password_wrong": ErrWrongCredentials,
+	"account_not_found":       ErrWrongCredentials,
+	"enter_2fa":               ErrTwoFactorRequired,
+	"wrong_2fa":               ErrWrongTwoFactorCode,
+	"max_storage_reached":     ErrQuotaExceeded,
+	"storage_limit_reached":   ErrQuotaExceeded,
+	"rate_limited":            ErrRateLimited,
+}
+
+// apiErrorStatuses maps HTTP status codes to sentinel errors, for errors with an unknown Filen error code.
+var apiErrorStatuses = map[int]error{
+	http.StatusNotFound:            ErrNotFound,
+	http.StatusUnauthorized:        ErrUnauthorized,
+	http.StatusInsufficientStorage: ErrQuotaExceeded,
+	http.StatusTooManyRequests:     ErrRateLimited,
+}
+
+// An APIError is an error reported by the API in its response.
+// Use errors.Is with the sentinel errors (e.g. [ErrNotFound]) to check for specific classes of errors.
+type APIError struct {
+	HTTPStatus int    // the HTTP status code of the response
+	Code       string // the Filen error code, e.g. "file_not_found"
+	Message    string // a human-readable description
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("response error: %s %s", e.Message, e.Code)
+}
+
+// Is reports whether the error belongs to the class denoted by a sentinel error.
+func (e *APIError) Is(target error) bool {
+	sentinel, ok := apiErrorCodes[e.Code]
+	if !ok {
+		sentinel = apiErrorStatuses[e.HTTPStatus]
+	}
+	return sentinel != nil && sentinel == target
+}
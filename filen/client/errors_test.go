@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	cases := []struct {
+		err      *APIError
+		sentinel error
+	}{
+		{&APIError{HTTPStatus: http.StatusOK, Code: "file_not_found"}, ErrNotFound},
+		{&APIError{HTTPStatus: http.StatusOK, Code: "enter_2fa"}, ErrTwoFactorRequired},
+		{&APIError{HTTPStatus: http.StatusOK, Code: "max_storage_reached"}, ErrQuotaExceeded},
+		{&APIError{HTTPStatus: http.StatusTooManyRequests, Code: "unknown_code"}, ErrRateLimited},
+		{&APIError{HTTPStatus: http.StatusNotFound, Code: ""}, ErrNotFound},
+	}
+	for _, c := range cases {
+		wrapped := fmt.Errorf("wrapped: %w", &RequestError{"Response error", "POST", GatewayURL("/"), c.err})
+		if !errors.Is(wrapped, c.sentinel) {
+			t.Errorf("expected %#v to be %v", c.err, c.sentinel)
+		}
+		if errors.Is(wrapped, ErrUnauthorized) {
+			t.Errorf("expected %#v not to be %v", c.err, ErrUnauthorized)
+		}
+	}
+}
+
+func TestAPIErrorFromResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"status":false,"message":"Invalid API key.","code":"api_key_not_found"}`))
+	}))
+	defer server.Close()
+
+	c := NewWithOptions(context.Background(), Options{Endpoints: SingleHostEndpoints(server.URL)}).Authorize("key")
+	_, err := c.GetV3UserBaseFolder(context.Background())
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+	if apiErr.HTTPStatus != http.StatusUnauthorized || apiErr.Code != "api_key_not_found" || apiErr.Message != "Invalid API key." {
+		t.Fatalf("unexpected APIError %#v", apiErr)
+	}
+}
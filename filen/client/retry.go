@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how requests which failed for transient reasons are retried.
+// Each retry is sent to the next host for the URL type (see [Endpoints]), so that a failing host is avoided.
+//
+// Requests which are known not to have been processed by the server are always retried:
+// when a connection to the host cannot be established, and on HTTP 408 and 429 responses.
+// Idempotent requests (see [FilenURL.Idempotent]) are also retried on other connection errors,
+// HTTP 5xx responses, and when a response body cannot be read completely.
+// Other requests, like moving an item, may already have been applied in those cases,
+// so they fail instead of being applied twice. Completing an upload is checked and retried if it was not applied.
+type RetryPolicy struct {
+	MaxAttempts int           // the number of attempts including the first one, 1 disables retries
+	BaseDelay   time.Duration // the delay before the first retry, doubled for each further retry
+	MaxDelay    time.Duration // the upper bound for a single delay, including one requested by Retry-After
+}
+
+// DefaultRetryPolicy is used when [Options.Retry] is the zero value.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+func (p RetryPolicy) orDefault() RetryPolicy {
+	if p == (RetryPolicy{}) {
+		return DefaultRetryPolicy
+	}
+	return p
+}
+
+// delay returns how long to wait before the given retry (starting at 1).
+// It uses exponential backoff with jitter, but waits at least as long as requested by the server.
+func (p RetryPolicy) delay(retry int, retryAfter time.Duration) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > 0 {
+		// jitter between 50% and 100% of the delay, so that concurrent chunk transfers don't retry in lockstep
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+	delay = max(delay, retryAfter)
+	if p.MaxDelay > 0 {
+		delay = min(delay, p.MaxDelay)
+	}
+	return delay
+}
+
+// retryableError marks an error from a single attempt which should be retried.
+type retryableError struct {
+	err        error
+	retryAfter time.Duration // the delay requested by the server, if any
+	notSent    bool          // whether the request is known not to have been applied by the server
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// isRetryableStatus reports whether a response with the given HTTP status code should be retried.
+func isRetryableStatus(status int) bool {
+	return isNotProcessedStatus(status) || status >= 500
+}
+
+// isNotProcessedStatus reports whether a response with the given HTTP status code means that the request was not processed.
+func isNotProcessedStatus(status int) bool {
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests
+}
+
+// isDialError reports whether err occurred while connecting to the host, i.e. before the request was sent.
+func isDialError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// parseRetryAfter parses the Retry-After header, which is either a number of seconds or an HTTP date.
+func parseRetryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// sendError returns a retryable RequestError from an error that occurred while sending an HTTP request.
+func sendError(ctx context.Context, method string, url *FilenURL, err error) error {
+	notSent := isDialError(err)
+	err = cannotSendError(method, url, err)
+	if ctx.Err() != nil {
+		return err
+	}
+	return &retryableError{err: err, notSent: notSent}
+}
+
+// statusError returns a retryable error if the response has a retryable status code, and nil otherwise.
+func statusError(method string, url *FilenURL, response *http.Response) error {
+	if !isRetryableStatus(response.StatusCode) {
+		return nil
+	}
+	err := &RequestError{fmt.Sprintf("Server responded with %s", response.Status), method, url, nil}
+	if apiRes, parseErr := parseResponse(method, url, response); parseErr == nil {
+		err.UnderlyingError = apiRes.CheckError()
+	}
+	return &retryableError{
+		err:        err,
+		retryAfter: parseRetryAfter(response.Header),
+		notSent:    isNotProcessedStatus(response.StatusCode),
+	}
+}
+
+// withRetries calls send until it succeeds, fails with an error that is not retryable,
+// or the retry policy is exhausted. Before each retry, url is switched to the next host.
+// Unless the request is idempotent, only attempts which are known not to have been processed are retried.
+func (uc *UnauthorizedClient) withRetries(ctx context.Context, method string, url *FilenURL, send func() error) error {
+	policy := uc.retryPolicy.orDefault()
+	idempotent := url.Idempotent || method == "GET"
+	for attempt := 1; ; attempt++ {
+		err := send()
+		var retryable *retryableError
+		if !errors.As(err, &retryable) {
+			return err
+		}
+		if !idempotent && !retryable.notSent {
+			if attempt > 1 {
+				return fmt.Errorf("giving up after %d attempts: %w", attempt, retryable.err)
+			}
+			return retryable.err
+		}
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			if attempt > 1 {
+				return fmt.Errorf("giving up after %d attempts: %w", attempt, retryable.err)
+			}
+			return retryable.err
+		}
+
+		timer := time.NewTimer(policy.delay(attempt, retryable.retryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return retryable.err
+		case <-timer.C:
+		}
+		url.failover(uc.baseURLs)
+	}
+}
@@ -8,33 +8,33 @@ import (
 var (
 	gatewayURLs = []string{
 		"https://gateway.filen.io",
-		/*"https://gateway.filen.net",
+		"https://gateway.filen.net",
 		"https://gateway.filen-1.net",
 		"https://gateway.filen-2.net",
 		"https://gateway.filen-3.net",
 		"https://gateway.filen-4.net",
 		"https://gateway.filen-5.net",
-		"https://gateway.filen-6.net",*/
+		"https://gateway.filen-6.net",
 	}
 	egestURLs = []string{
 		"https://egest.filen.io",
-		/*"https://egest.filen.net",
+		"https://egest.filen.net",
 		"https://egest.filen-1.net",
 		"https://egest.filen-2.net",
 		"https://egest.filen-3.net",
 		"https://egest.filen-4.net",
 		"https://egest.filen-5.net",
-		"https://egest.filen-6.net",*/
+		"https://egest.filen-6.net",
 	}
 	ingestURLs = []string{
 		"https://ingest.filen.io",
-		/*"https://ingest.filen.net",
+		"https://ingest.filen.net",
 		"https://ingest.filen-1.net",
 		"https://ingest.filen-2.net",
 		"https://ingest.filen-3.net",
 		"https://ingest.filen-4.net",
 		"https://ingest.filen-5.net",
-		"https://ingest.filen-6.net",*/
+		"https://ingest.filen-6.net",
 	}
 )
 
@@ -44,10 +44,62 @@ const (
 	URLTypeGateway = 3
 )
 
+// baseURLs holds the hosts requests are sent to, indexed by URL type.
+type baseURLs map[int][]string
+
+var defaultBaseURLs = baseURLs{
+	URLTypeIngest:  ingestURLs,
+	URLTypeEgest:   egestURLs,
+	URLTypeGateway: gatewayURLs,
+}
+
+// Endpoints lists the base URLs (scheme and host, without a trailing slash) requests are sent to.
+// One is picked at random for each request. Empty lists fall back to the Filen hosts.
+type Endpoints struct {
+	Gateway []string // the API
+	Egest   []string // file chunk downloads
+	Ingest  []string // file chunk uploads
+}
+
+// SingleHostEndpoints returns Endpoints which send every request to baseURL.
+func SingleHostEndpoints(baseURL string) Endpoints {
+	hosts := []string{baseURL}
+	return Endpoints{
+		Gateway: hosts,
+		Egest:   hosts,
+		Ingest:  hosts,
+	}
+}
+
+func (e Endpoints) baseURLs() baseURLs {
+	bases := baseURLs{
+		URLTypeIngest:  e.Ingest,
+		URLTypeEgest:   e.Egest,
+		URLTypeGateway: e.Gateway,
+	}
+	for urlType, hosts := range bases {
+		if len(hosts) == 0 {
+			bases[urlType] = defaultBaseURLs[urlType]
+			continue
+		}
+		trimmed := make([]string, len(hosts))
+		for i, host := range hosts {
+			trimmed[i] = strings.TrimSuffix(host, "/")
+		}
+		bases[urlType] = trimmed
+	}
+	return bases
+}
+
 type FilenURL struct {
 	Type      int
 	Path      string
 	CachedUrl string
+	// Idempotent marks a request which can safely be applied more than once,
+	// so that it is retried even if it may have reached the server (see [RetryPolicy]).
+	// GET requests are always considered idempotent.
+	Idempotent bool
+	host       int // index of the host used for CachedUrl
 }
 
 func GatewayURL(path string) *FilenURL {
@@ -58,16 +110,26 @@ func GatewayURL(path string) *FilenURL {
 	}
 }
 
+// IdempotentGatewayURL returns a gateway URL for a request which only reads data or sets it to a fixed value,
+// see [FilenURL.Idempotent].
+func IdempotentGatewayURL(path string) *FilenURL {
+	url := GatewayURL(path)
+	url.Idempotent = true
+	return url
+}
+
 func (url *FilenURL) String() string {
+	return url.resolve(defaultBaseURLs)
+}
+
+// resolve picks a random host for the URL's type from bases and caches the result.
+func (url *FilenURL) resolve(bases baseURLs) string {
 	if url.CachedUrl == "" {
 		var builder strings.Builder
-		switch url.Type {
-		case URLTypeIngest:
-			builder.WriteString(ingestURLs[rand.Intn(len(ingestURLs))])
-		case URLTypeEgest:
-			builder.WriteString(egestURLs[rand.Intn(len(egestURLs))])
-		case URLTypeGateway:
-			builder.WriteString(gatewayURLs[rand.Intn(len(gatewayURLs))])
+		hosts := bases[url.Type]
+		if len(hosts) > 0 {
+			url.host = rand.Intn(len(hosts))
+			builder.WriteString(hosts[url.host])
 		}
 		builder.WriteString(url.Path)
 		url.CachedUrl = builder.String()
@@ -75,3 +137,13 @@ func (url *FilenURL) String() string {
 
 	return url.CachedUrl
 }
+
+// failover switches to the next host for the URL's type, so that a retry doesn't hit the same host.
+func (url *FilenURL) failover(bases baseURLs) {
+	hosts := bases[url.Type]
+	if len(hosts) == 0 {
+		return
+	}
+	url.host = (url.host + 1) % len(hosts)
+	url.CachedUrl = hosts[url.host] + url.Path
+}
@@ -14,7 +14,7 @@ type V3AuthInfoResponse struct {
 // PostV3AuthInfo calls /v3/auth/info.
 func (uc *UnauthorizedClient) PostV3AuthInfo(ctx context.Context, email string) (*V3AuthInfoResponse, error) {
 	authInfo := &V3AuthInfoResponse{}
-	_, err := uc.RequestData(ctx, "POST", GatewayURL("/v3/auth/info"), v3authInfoRequest{
+	_, err := uc.RequestData(ctx, "POST", IdempotentGatewayURL("/v3/auth/info"), v3authInfoRequest{
 		Email: email,
 	}, authInfo)
 	return authInfo, err
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+)
+
+type v3DirRequest struct {
+	UUID string `json:"uuid"`
+}
+
+type V3DirResponse struct {
+	UUID       string                 `json:"uuid"`
+	Metadata   crypto.EncryptedString `json:"nameEncrypted"` // nameEncrypted is actually the metadata
+	NameHashed string                 `json:"nameHashed"`
+	Parent     string                 `json:"parent"`
+	Trash      bool                   `json:"trash"`
+	Favorited  bool                   `json:"favorited"`
+	Color      types.DirColor         `json:"color"`
+}
+
+// PostV3Dir calls /v3/dir to fetch a directory by its UUID, including directories in the trash.
+func (c *Client) PostV3Dir(ctx context.Context, uuid string) (*V3DirResponse, error) {
+	response := &V3DirResponse{}
+	_, err := c.RequestData(ctx, "POST", IdempotentGatewayURL("/v3/dir"), v3DirRequest{
+		UUID: uuid,
+	}, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
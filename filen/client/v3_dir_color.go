@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+)
+
+type v3DirColorRequest struct {
+	UUID  string `json:"uuid"`
+	Color string `json:"color"`
+}
+
+// PostV3DirColor calls /v3/dir/color to change the color of a directory.
+func (c *Client) PostV3DirColor(ctx context.Context, uuid string, color types.DirColor) error {
+	// the API calls the default color "default"
+	colorString := string(color)
+	if color == types.DirColorDefault {
+		colorString = "default"
+	}
+	_, err := c.Request(ctx, "POST", IdempotentGatewayURL("/v3/dir/color"), v3DirColorRequest{
+		UUID:  uuid,
+		Color: colorString,
+	})
+	return err
+}
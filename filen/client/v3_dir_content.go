@@ -6,6 +6,12 @@ import (
 	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
 )
 
+// TrashUUID can be passed to [Client.PostV3DirContent] instead of a directory UUID to list the items in the trash.
+const TrashUUID = "trash"
+
+// FavoritesUUID can be passed to [Client.PostV3DirContent] instead of a directory UUID to list the favorite items.
+const FavoritesUUID = "favorites"
+
 type v3dirContentRequest struct {
 	UUID string `json:"uuid"`
 }
@@ -39,7 +45,7 @@ type V3DirContentResponse struct {
 // PostV3DirContent calls /v3/dir/content.
 func (c *Client) PostV3DirContent(ctx context.Context, uuid string) (*V3DirContentResponse, error) {
 	directoryContent := &V3DirContentResponse{}
-	_, err := c.RequestData(ctx, "POST", GatewayURL("/v3/dir/content"), v3dirContentRequest{
+	_, err := c.RequestData(ctx, "POST", IdempotentGatewayURL("/v3/dir/content"), v3dirContentRequest{
 		UUID: uuid,
 	}, directoryContent)
 	return directoryContent, err
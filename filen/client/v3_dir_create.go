@@ -16,10 +16,11 @@ type V3CreateDirResponse struct {
 	UUID string `json:"uuid"`
 }
 
-// PostV3DirCreate calls /v3/dir/create
+// PostV3DirCreate calls /v3/dir/create.
+// If parentUUID already contains a directory with the same name, the response holds the UUID of that directory.
 func (c *Client) PostV3DirCreate(ctx context.Context, uuid string, name crypto.EncryptedString, nameHashed string, parentUUID string) (*V3CreateDirResponse, error) {
 	response := &V3CreateDirResponse{}
-	_, err := c.RequestData(ctx, "POST", GatewayURL("/v3/dir/create"), v3createDirRequest{
+	_, err := c.RequestData(ctx, "POST", IdempotentGatewayURL("/v3/dir/create"), v3createDirRequest{
 		UUID:       uuid,
 		Name:       name,
 		NameHashed: nameHashed,
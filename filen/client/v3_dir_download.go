@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+)
+
+type v3DirDownloadRequest struct {
+	UUID string `json:"uuid"`
+	Type string `json:"type"`
+}
+
+type V3DirDownloadResponse struct {
+	Files []struct {
+		UUID      string                 `json:"uuid"`
+		Bucket    string                 `json:"bucket"`
+		Region    string                 `json:"region"`
+		Chunks    int                    `json:"chunks"`
+		Parent    string                 `json:"parent"`
+		Metadata  crypto.EncryptedString `json:"metadata"`
+		Version   int                    `json:"version"`
+		Timestamp int                    `json:"timestamp"`
+		Favorited int                    `json:"favorited"`
+	} `json:"files"`
+	Folders []struct {
+		UUID      string                 `json:"uuid"`
+		Metadata  crypto.EncryptedString `json:"name"` // name is actually the metadata
+		Parent    string                 `json:"parent"`
+		Color     types.DirColor         `json:"color"`
+		Timestamp int                    `json:"timestamp"`
+		Favorited int                    `json:"favorited"`
+	} `json:"folders"`
+}
+
+// PostV3DirDownload calls /v3/dir/download to list all files and directories below a directory in one request.
+// The listed folders include the directory itself.
+func (c *Client) PostV3DirDownload(ctx context.Context, uuid string) (*V3DirDownloadResponse, error) {
+	response := &V3DirDownloadResponse{}
+	_, err := c.RequestData(ctx, "POST", IdempotentGatewayURL("/v3/dir/download"), v3DirDownloadRequest{
+		UUID: uuid,
+		Type: "normal",
+	}, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
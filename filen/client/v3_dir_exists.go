@@ -0,0 +1,17 @@
+package client
+
+import "context"
+
+// PostV3DirExists calls /v3/dir/exists to check whether the directory with the UUID parent contains a directory
+// with the hashed name.
+func (c *Client) PostV3DirExists(ctx context.Context, parent string, nameHashed string) (*V3ExistsResponse, error) {
+	response := &V3ExistsResponse{}
+	_, err := c.RequestData(ctx, "POST", IdempotentGatewayURL("/v3/dir/exists"), v3ExistsRequest{
+		Parent:     parent,
+		NameHashed: nameHashed,
+	}, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,12 @@
+package client
+
+import "context"
+
+// PostV3DirMove calls /v3/dir/move to move a directory to the directory with the UUID to.
+func (c *Client) PostV3DirMove(ctx context.Context, uuid string, to string) error {
+	_, err := c.Request(ctx, "POST", GatewayURL("/v3/dir/move"), v3MoveRequest{
+		UUID: uuid,
+		To:   to,
+	})
+	return err
+}
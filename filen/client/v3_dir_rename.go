@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
+)
+
+type v3DirRenameRequest struct {
+	UUID       string                 `json:"uuid"`
+	Name       crypto.EncryptedString `json:"name"` // name is actually the metadata
+	NameHashed string                 `json:"nameHashed"`
+}
+
+// PostV3DirRename calls /v3/dir/rename.
+func (c *Client) PostV3DirRename(ctx context.Context, uuid string, name crypto.EncryptedString, nameHashed string) error {
+	_, err := c.Request(ctx, "POST", GatewayURL("/v3/dir/rename"), v3DirRenameRequest{
+		UUID:       uuid,
+		Name:       name,
+		NameHashed: nameHashed,
+	})
+	return err
+}
@@ -0,0 +1,15 @@
+package client
+
+import "context"
+
+type v3DirRestoreRequest struct {
+	UUID string `json:"uuid"`
+}
+
+// PostV3DirRestore calls /v3/dir/restore to restore a directory from the trash.
+func (c *Client) PostV3DirRestore(ctx context.Context, uuid string) error {
+	_, err := c.Request(ctx, "POST", GatewayURL("/v3/dir/restore"), v3DirRestoreRequest{
+		UUID: uuid,
+	})
+	return err
+}
@@ -0,0 +1,29 @@
+package client
+
+import "context"
+
+type v3DirSizeRequest struct {
+	UUID       string `json:"uuid"`
+	SharerID   int    `json:"sharerId"`
+	ReceiverID int    `json:"receiverId"`
+	Trash      bool   `json:"trash"`
+}
+
+type V3DirSizeResponse struct {
+	Size    int64 `json:"size"`
+	Files   int   `json:"files"`
+	Folders int   `json:"folders"`
+}
+
+// PostV3DirSize calls /v3/dir/size to fetch the total size and the number of files and directories
+// below a directory.
+func (c *Client) PostV3DirSize(ctx context.Context, uuid string) (*V3DirSizeResponse, error) {
+	response := &V3DirSizeResponse{}
+	_, err := c.RequestData(ctx, "POST", IdempotentGatewayURL("/v3/dir/size"), v3DirSizeRequest{
+		UUID: uuid,
+	}, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
+)
+
+type v3FileRequest struct {
+	UUID string `json:"uuid"`
+}
+
+type V3FileResponse struct {
+	UUID          string                 `json:"uuid"`
+	Region        string                 `json:"region"`
+	Bucket        string                 `json:"bucket"`
+	NameEncrypted crypto.EncryptedString `json:"nameEncrypted"`
+	NameHashed    string                 `json:"nameHashed"`
+	SizeEncrypted crypto.EncryptedString `json:"sizeEncrypted"`
+	MimeEncrypted crypto.EncryptedString `json:"mimeEncrypted"`
+	Metadata      crypto.EncryptedString `json:"metadata"`
+	Size          int                    `json:"size"`
+	Parent        string                 `json:"parent"`
+	Versioned     bool                   `json:"versioned"`
+	Trash         bool                   `json:"trash"`
+	Version       int                    `json:"version"`
+}
+
+// PostV3File calls /v3/file to fetch a file by its UUID, including files in the trash.
+func (c *Client) PostV3File(ctx context.Context, uuid string) (*V3FileResponse, error) {
+	response := &V3FileResponse{}
+	_, err := c.RequestData(ctx, "POST", IdempotentGatewayURL("/v3/file"), v3FileRequest{
+		UUID: uuid,
+	}, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,27 @@
+package client
+
+import "context"
+
+type v3ExistsRequest struct {
+	Parent     string `json:"parent"`
+	NameHashed string `json:"nameHashed"`
+}
+
+type V3ExistsResponse struct {
+	Exists bool   `json:"exists"`
+	UUID   string `json:"uuid"` // the UUID of the existing item, if any
+}
+
+// PostV3FileExists calls /v3/file/exists to check whether the directory with the UUID parent contains a file
+// with the hashed name.
+func (c *Client) PostV3FileExists(ctx context.Context, parent string, nameHashed string) (*V3ExistsResponse, error) {
+	response := &V3ExistsResponse{}
+	_, err := c.RequestData(ctx, "POST", IdempotentGatewayURL("/v3/file/exists"), v3ExistsRequest{
+		Parent:     parent,
+		NameHashed: nameHashed,
+	}, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
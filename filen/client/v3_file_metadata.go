@@ -14,7 +14,7 @@ type v3FileMetadataRequest struct {
 }
 
 func (c *Client) PostV3FileMetadata(ctx context.Context, uuid string, name crypto.EncryptedString, nameHashed string, metadata crypto.EncryptedString) error {
-	_, err := c.Request(ctx, "POST", GatewayURL("/v3/file/metadata"), v3FileMetadataRequest{
+	_, err := c.Request(ctx, "POST", IdempotentGatewayURL("/v3/file/metadata"), v3FileMetadataRequest{
 		UUID:       uuid,
 		Name:       name,
 		NameHashed: nameHashed,
@@ -0,0 +1,17 @@
+package client
+
+import "context"
+
+type v3MoveRequest struct {
+	UUID string `json:"uuid"`
+	To   string `json:"to"`
+}
+
+// PostV3FileMove calls /v3/file/move to move a file to the directory with the UUID to.
+func (c *Client) PostV3FileMove(ctx context.Context, uuid string, to string) error {
+	_, err := c.Request(ctx, "POST", GatewayURL("/v3/file/move"), v3MoveRequest{
+		UUID: uuid,
+		To:   to,
+	})
+	return err
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
+)
+
+type v3FileRenameRequest struct {
+	UUID       string                 `json:"uuid"`
+	Name       crypto.EncryptedString `json:"name"`
+	NameHashed string                 `json:"nameHashed"`
+	Metadata   crypto.EncryptedString `json:"metadata"`
+}
+
+// PostV3FileRename calls /v3/file/rename.
+// The name is encrypted with the file key, the metadata contains the new name as well.
+func (c *Client) PostV3FileRename(ctx context.Context, uuid string, name crypto.EncryptedString, nameHashed string, metadata crypto.EncryptedString) error {
+	_, err := c.Request(ctx, "POST", GatewayURL("/v3/file/rename"), v3FileRenameRequest{
+		UUID:       uuid,
+		Name:       name,
+		NameHashed: nameHashed,
+		Metadata:   metadata,
+	})
+	return err
+}
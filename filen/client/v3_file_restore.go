@@ -0,0 +1,15 @@
+package client
+
+import "context"
+
+type v3fileRestoreRequest struct {
+	UUID string `json:"uuid"`
+}
+
+// PostV3FileRestore calls /v3/file/restore to restore a file from the trash.
+func (c *Client) PostV3FileRestore(ctx context.Context, uuid string) error {
+	_, err := c.Request(ctx, "POST", GatewayURL("/v3/file/restore"), v3fileRestoreRequest{
+		UUID: uuid,
+	})
+	return err
+}
@@ -0,0 +1,18 @@
+package client
+
+import "context"
+
+type v3FileVersionRestoreRequest struct {
+	UUID    string `json:"uuid"`
+	Current string `json:"current"`
+}
+
+// PostV3FileVersionRestore calls /v3/file/version/restore to make the version with the given UUID
+// the current version of the file whose current version has the UUID current.
+func (c *Client) PostV3FileVersionRestore(ctx context.Context, uuid string, current string) error {
+	_, err := c.Request(ctx, "POST", GatewayURL("/v3/file/version/restore"), v3FileVersionRestoreRequest{
+		UUID:    uuid,
+		Current: current,
+	})
+	return err
+}
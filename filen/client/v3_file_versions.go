@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
+)
+
+type v3FileVersionsRequest struct {
+	UUID string `json:"uuid"`
+}
+
+type V3FileVersionsResponse struct {
+	Versions []struct {
+		UUID      string                 `json:"uuid"`
+		Bucket    string                 `json:"bucket"`
+		Region    string                 `json:"region"`
+		Chunks    int                    `json:"chunks"`
+		Metadata  crypto.EncryptedString `json:"metadata"`
+		Rm        string                 `json:"rm"`
+		Timestamp int                    `json:"timestamp"`
+		Version   int                    `json:"version"`
+	} `json:"versions"`
+}
+
+// PostV3FileVersions calls /v3/file/versions to list all versions of a file.
+func (c *Client) PostV3FileVersions(ctx context.Context, uuid string) (*V3FileVersionsResponse, error) {
+	response := &V3FileVersionsResponse{}
+	_, err := c.RequestData(ctx, "POST", IdempotentGatewayURL("/v3/file/versions"), v3FileVersionsRequest{
+		UUID: uuid,
+	}, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,24 @@
+package client
+
+import "context"
+
+type v3ItemFavoriteRequest struct {
+	UUID  string `json:"uuid"`
+	Type  string `json:"type"`
+	Value int    `json:"value"`
+}
+
+// PostV3ItemFavorite calls /v3/item/favorite to mark a file or directory as a favorite or unmark it.
+// itemType is "file" or "folder".
+func (c *Client) PostV3ItemFavorite(ctx context.Context, uuid string, itemType string, favorite bool) error {
+	value := 0
+	if favorite {
+		value = 1
+	}
+	_, err := c.Request(ctx, "POST", IdempotentGatewayURL("/v3/item/favorite"), v3ItemFavoriteRequest{
+		UUID:  uuid,
+		Type:  itemType,
+		Value: value,
+	})
+	return err
+}
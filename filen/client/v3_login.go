@@ -20,13 +20,21 @@ type V3LoginResponse struct {
 	DEK        crypto.EncryptedString `json:"dek"`
 }
 
+// noTwoFactorCode is sent as the two-factor code for accounts without two-factor authentication.
+const noTwoFactorCode = "XXXXXX"
+
 // PostV3Login calls /v3/login.
-func (uc *UnauthorizedClient) PostV3Login(ctx context.Context, email string, password crypto.DerivedPassword, authVersion int) (*V3LoginResponse, error) {
+// The twoFactorCode (or the two-factor recovery key) is only required if the account has two-factor authentication enabled,
+// otherwise it can be empty.
+func (uc *UnauthorizedClient) PostV3Login(ctx context.Context, email string, password crypto.DerivedPassword, authVersion int, twoFactorCode string) (*V3LoginResponse, error) {
+	if twoFactorCode == "" {
+		twoFactorCode = noTwoFactorCode
+	}
 	response := &V3LoginResponse{}
-	_, err := uc.RequestData(ctx, "POST", GatewayURL("/v3/login"), v3loginRequest{
+	_, err := uc.RequestData(ctx, "POST", IdempotentGatewayURL("/v3/login"), v3loginRequest{
 		Email:         email,
 		Password:      string(password),
-		TwoFactorCode: "XXXXXX",
+		TwoFactorCode: twoFactorCode,
 		AuthVersion:   authVersion,
 	}, response)
 	return response, err
@@ -22,15 +22,21 @@ func (c *Client) PostV3Upload(ctx context.Context, uuid string, chunkIdx int, pa
 		Type: URLTypeIngest,
 		Path: fmt.Sprintf("/v3/upload?uuid=%s&index=%v&parent=%s&uploadKey=%s&hash=%s",
 			uuid, chunkIdx, parentUUID, uploadKey, dataHash),
+		// uploading a chunk again replaces it
+		Idempotent: true,
 	}
 	method := "POST"
-	// Can't use the standard Client.RequestData because our request body is raw bytes
-	req, err := c.buildReaderRequest(ctx, method, url, bytes.NewBuffer(data))
-	if err != nil {
-		return nil, err
-	}
-
-	response, err := handleRequest(req, &c.httpClient, method, url)
+	var response *aPIResponse
+	err := c.withRetries(ctx, method, url, func() error {
+		// Can't use the standard Client.RequestData because our request body is raw bytes
+		req, err := c.buildReaderRequest(ctx, method, url, c.limitReader(ctx, bytes.NewReader(data), url.Type))
+		if err != nil {
+			return err
+		}
+		req.ContentLength = int64(len(data))
+		response, err = handleRequest(req, &c.httpClient, method, url)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"errors"
 )
 
 type V3UploadDoneRequest struct {
@@ -17,9 +18,37 @@ type V3UploadDoneResponse struct {
 }
 
 // PostV3UploadDone calls /v3/upload/done.
+//
+// Completing an upload twice fails, so a failure after which the request may have been applied
+// (see [RetryPolicy]) is checked with /v3/file: if the file exists, the upload was completed,
+// otherwise the request is retried.
 func (c *Client) PostV3UploadDone(ctx context.Context, request V3UploadDoneRequest) (*V3UploadDoneResponse, error) {
+	method, url := "POST", GatewayURL("/v3/upload/done")
 	response := &V3UploadDoneResponse{}
-	_, err := c.RequestData(ctx, "POST", GatewayURL("/v3/upload/done"), request, response)
+	err := c.withRetries(ctx, method, url, func() error {
+		req, err := c.buildJSONRequest(ctx, method, url, request)
+		if err != nil {
+			return err
+		}
+		apiRes, err := handleRequest(req, &c.httpClient, method, url)
+		var retryable *retryableError
+		if errors.As(err, &retryable) && !retryable.notSent {
+			file, fileErr := c.PostV3File(ctx, request.UUID)
+			if fileErr == nil {
+				response = &V3UploadDoneResponse{Chunks: request.Chunks, Size: file.Size}
+				return nil
+			}
+			if errors.Is(fileErr, ErrNotFound) {
+				// the upload was not completed, so it is safe to send the request again
+				retryable.notSent = true
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		return convertIntoResponseData(method, url, apiRes, response)
+	})
 	if err != nil {
 		return nil, err
 	}
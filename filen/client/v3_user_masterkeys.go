@@ -16,7 +16,7 @@ type V3UserMasterKeysResponse struct {
 // PostV3UserMasterKeys calls /v3/user/masterKeys.
 func (c *Client) PostV3UserMasterKeys(ctx context.Context, encryptedMasterKey crypto.EncryptedString) (*V3UserMasterKeysResponse, error) {
 	userMasterKeys := &V3UserMasterKeysResponse{}
-	_, err := c.RequestData(ctx, "POST", GatewayURL("/v3/user/masterKeys"), v3userMasterKeysRequest{
+	_, err := c.RequestData(ctx, "POST", IdempotentGatewayURL("/v3/user/masterKeys"), v3userMasterKeysRequest{
 		MasterKey: encryptedMasterKey,
 	}, userMasterKeys)
 	return userMasterKeys, err
@@ -16,20 +16,19 @@ import (
 // FindItem find a cloud item by its path and returns it (either the File or the Directory will be returned).
 // Set requireDirectory to differentiate between files and directories with the same path (otherwise, the file will be found).
 // Returns nil for both File and Directory if none was found.
+// If the path cache is enabled (see [Filen.EnablePathCache]), the listing starts at the deepest cached ancestor.
+// The parent of the item is always listed, so that a file is found even if a directory with the same name is cached.
 func (api *Filen) FindItem(ctx context.Context, path string) (types.FileSystemObject, error) {
-
-	var currentDir types.DirectoryInterface = &api.BaseFolder
-	segments := strings.Split(path, "/")
-	if len(strings.Join(segments, "")) == 0 {
-		return currentDir, nil
+	segments := strings.Split(normalizePath(path), "/")
+	if segments[0] == "" {
+		return &api.BaseFolder, nil
 	}
 
-SegmentsLoop:
-	for segmentIdx, segment := range segments {
-		if segment == "" {
-			continue
-		}
+	currentDir, resolved := api.deepestCached(segments[:len(segments)-1])
 
+SegmentsLoop:
+	for segmentIdx := resolved; segmentIdx < len(segments); segmentIdx++ {
+		segment := segments[segmentIdx]
 		files, directories, err := api.ReadDirectory(ctx, currentDir)
 		if err != nil {
 			return nil, fmt.Errorf("read directory: %w", err)
@@ -41,6 +40,7 @@ SegmentsLoop:
 		}
 		for _, directory := range directories {
 			if directory.Name == segment {
+				api.pathCache.put(strings.Join(segments[:segmentIdx+1], "/"), directory)
 				if segmentIdx == len(segments)-1 {
 					return directory, nil
 				} else {
@@ -87,15 +87,15 @@ func (api *Filen) FindDirectory(ctx context.Context, path string) (types.Directo
 // FindDirectoryOrCreate finds a cloud directory by its path and returns its UUID.
 // If the directory cannot be found, it (and all non-existent parent directories) will be created.
 func (api *Filen) FindDirectoryOrCreate(ctx context.Context, path string) (types.DirectoryInterface, error) {
-	segments := strings.Split(path, "/")
+	segments := strings.Split(normalizePath(path), "/")
+	if segments[0] == "" {
+		return &api.BaseFolder, nil
+	}
 
-	var currentDir types.DirectoryInterface = &api.BaseFolder
+	currentDir, resolved := api.deepestCached(segments)
 SegmentsLoop:
-	for _, segment := range segments {
-		if segment == "" {
-			continue
-		}
-
+	for segmentIdx := resolved; segmentIdx < len(segments); segmentIdx++ {
+		segment := segments[segmentIdx]
 		_, directories, err := api.ReadDirectory(ctx, currentDir)
 		if err != nil {
 			return nil, err
@@ -103,11 +103,12 @@ SegmentsLoop:
 		for _, directory := range directories {
 			if directory.Name == segment {
 				// directory found
+				api.pathCache.put(strings.Join(segments[:segmentIdx+1], "/"), directory)
 				currentDir = directory
 				continue SegmentsLoop
 			}
 		}
-		// create directory
+		// create directory (which adds it to the path cache)
 		directory, err := api.CreateDirectory(ctx, currentDir, segment)
 		if err != nil {
 			return nil, err
@@ -117,82 +118,244 @@ SegmentsLoop:
 	return currentDir, nil
 }
 
+// ReadDirectoryOptions configures [Filen.ReadDirectoryWithOptions].
+type ReadDirectoryOptions struct {
+	// SkipUndecryptable leaves out entries whose metadata cannot be decrypted or parsed,
+	// instead of failing the whole listing.
+	SkipUndecryptable bool
+	// OnSkip, if set, is called for every entry left out because of SkipUndecryptable.
+	OnSkip func(err *ItemDecryptionError)
+}
+
+// itemError returns the error to fail a listing with because an item cannot be decrypted,
+// or nil if the item is skipped.
+func (opts ReadDirectoryOptions) itemError(uuid string, directory bool, err error) error {
+	itemErr := &ItemDecryptionError{UUID: uuid, Directory: directory, Err: err}
+	if !opts.SkipUndecryptable {
+		return itemErr
+	}
+	if opts.OnSkip != nil {
+		opts.OnSkip(itemErr)
+	}
+	return nil
+}
+
+// An ItemDecryptionError reports a file or directory whose metadata cannot be decrypted or parsed.
+type ItemDecryptionError struct {
+	UUID      string // the UUID of the cloud item
+	Directory bool   // whether the item is a directory
+	Err       error
+}
+
+func (e *ItemDecryptionError) Error() string {
+	kind := "file"
+	if e.Directory {
+		kind = "directory"
+	}
+	return fmt.Sprintf("%s %s: %v", kind, e.UUID, e.Err)
+}
+
+func (e *ItemDecryptionError) Unwrap() error {
+	return e.Err
+}
+
+// decryptFileMetadata decrypts and parses the metadata of a file, including the file's encryption key.
+func (api *Filen) decryptFileMetadata(encrypted crypto.EncryptedString) (*FileMetadata, *crypto.EncryptionKey, error) {
+	metadataStr, err := api.DecryptMeta(encrypted)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decrypting metadata: %w", err)
+	}
+	var metadata FileMetadata
+	err = json.Unmarshal([]byte(metadataStr), &metadata)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unmarshalling metadata: %w", err)
+	}
+	encryptionKey, err := crypto.MakeEncryptionKeyFromUnknownStr(metadata.Key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating encryption key: %w", err)
+	}
+	return &metadata, encryptionKey, nil
+}
+
+// encryptFileMetadata encrypts the metadata of a file, which includes its name and encryption key.
+func (api *Filen) encryptFileMetadata(file *types.File) (crypto.EncryptedString, error) {
+	metadata := FileMetadata{
+		Name:         file.Name,
+		Size:         file.Size,
+		MimeType:     file.MimeType,
+		Key:          file.EncryptionKey.ToStringWithAuthVersion(api.AuthVersion),
+		LastModified: int(file.LastModified.UnixMilli()),
+		Created:      int(file.Created.UnixMilli()),
+		Hash:         file.Hash,
+	}
+	metadataStr, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("marshal file metadata: %w", err)
+	}
+	return api.EncryptMeta(string(metadataStr)), nil
+}
+
+// encryptDirectoryMetadata encrypts the metadata of a directory with the given name and creation time.
+func (api *Filen) encryptDirectoryMetadata(name string, created time.Time) (crypto.EncryptedString, error) {
+	metadata := types.DirectoryMetaData{
+		Name:     name,
+		Creation: int(created.UnixMilli()),
+	}
+	metadataStr, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("marshal directory metadata: %w", err)
+	}
+	return api.EncryptMeta(string(metadataStr)), nil
+}
+
+// decryptDirectoryMetadata decrypts and parses the metadata of a directory.
+func (api *Filen) decryptDirectoryMetadata(encrypted crypto.EncryptedString) (*types.DirectoryMetaData, error) {
+	metaStr, err := api.DecryptMeta(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting metadata: %w", err)
+	}
+	metaData := types.DirectoryMetaData{}
+	err = json.Unmarshal([]byte(metaStr), &metaData)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling metadata: %w", err)
+	}
+	return &metaData, nil
+}
+
+// newFile decrypts the metadata of a file listed by the API.
+func (api *Filen) newFile(uuid string, parent string, metadata crypto.EncryptedString, region string, bucket string, chunks int, favorited bool) (*types.File, error) {
+	decrypted, encryptionKey, err := api.decryptFileMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &types.File{
+		IncompleteFile: types.IncompleteFile{
+			UUID:          uuid,
+			Name:          decrypted.Name,
+			MimeType:      decrypted.MimeType,
+			EncryptionKey: *encryptionKey,
+			Created:       util.TimestampToTime(int64(decrypted.Created)),
+			LastModified:  util.TimestampToTime(int64(decrypted.LastModified)),
+			ParentUUID:    parent,
+		},
+		Size:      decrypted.Size,
+		Favorited: favorited,
+		Region:    region,
+		Bucket:    bucket,
+		Chunks:    chunks,
+		Hash:      decrypted.Hash,
+	}, nil
+}
+
+// newDirectory decrypts the metadata of a directory listed by the API.
+func (api *Filen) newDirectory(uuid string, parent string, metadata crypto.EncryptedString, color types.DirColor, timestamp int, favorited bool) (*types.Directory, error) {
+	decrypted, err := api.decryptDirectoryMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+	creationTimestamp := decrypted.Creation
+	if creationTimestamp == 0 {
+		creationTimestamp = timestamp
+	}
+	return &types.Directory{
+		UUID:       uuid,
+		Name:       decrypted.Name,
+		ParentUUID: parent,
+		Color:      color,
+		Created:    util.TimestampToTime(int64(creationTimestamp)),
+		Favorited:  favorited,
+	}, nil
+}
+
 // ReadDirectory fetches the files and directories that are children of a directory (specified by UUID).
+// It fails if the metadata of any entry cannot be decrypted; see [Filen.ReadDirectoryWithOptions] to skip those.
 func (api *Filen) ReadDirectory(ctx context.Context, dir types.DirectoryInterface) ([]*types.File, []*types.Directory, error) {
+	return api.ReadDirectoryWithOptions(ctx, dir, ReadDirectoryOptions{})
+}
+
+// ReadDirectoryWithOptions is like [Filen.ReadDirectory], configured by opts.
+// Entries that cannot be decrypted result in an error wrapping an [*ItemDecryptionError], unless they are skipped.
+func (api *Filen) ReadDirectoryWithOptions(ctx context.Context, dir types.DirectoryInterface, opts ReadDirectoryOptions) ([]*types.File, []*types.Directory, error) {
+	return api.readDirectory(ctx, dir.GetUUID(), opts)
+}
+
+// readDirectory lists the directory with the given UUID, which may also be [client.TrashUUID].
+func (api *Filen) readDirectory(ctx context.Context, uuid string, opts ReadDirectoryOptions) ([]*types.File, []*types.Directory, error) {
 	// fetch directory content
-	directoryContent, err := api.Client.PostV3DirContent(ctx, dir.GetUUID())
+	directoryContent, err := api.Client.PostV3DirContent(ctx, uuid)
 	if err != nil {
 		return nil, nil, fmt.Errorf("ReadDirectory fetching directory: %w", err)
 	}
 
+	handleItemError := func(uuid string, directory bool, err error) error {
+		if err = opts.itemError(uuid, directory, err); err != nil {
+			return fmt.Errorf("ReadDirectory: %w", err)
+		}
+		return nil
+	}
+
 	// transform files
 	files := make([]*types.File, 0)
 	for _, file := range directoryContent.Uploads {
-		metadataStr, err := api.DecryptMeta(file.Metadata)
-		if err != nil {
-			return nil, nil, fmt.Errorf("ReadDirectory decrypting metadata: %v", err)
-		}
-		var metadata FileMetadata
-		err = json.Unmarshal([]byte(metadataStr), &metadata)
+		f, err := api.newFile(file.UUID, file.Parent, file.Metadata, file.Region, file.Bucket, file.Chunks, file.Favorited == 1)
 		if err != nil {
-			return nil, nil, fmt.Errorf("ReadDirectory unmarshalling metadata: %v", err)
-		}
-
-		if len(metadata.Key) != 32 {
-
-		}
-		encryptionKey, err := crypto.MakeEncryptionKeyFromUnknownStr(metadata.Key)
-		if err != nil {
-			return nil, nil, fmt.Errorf("ReadDirectory creating encryption key: %v", err)
+			if err = handleItemError(file.UUID, false, err); err != nil {
+				return nil, nil, err
+			}
+			continue
 		}
-
-		files = append(files, &types.File{
-			IncompleteFile: types.IncompleteFile{
-				UUID:          file.UUID,
-				Name:          metadata.Name,
-				MimeType:      metadata.MimeType,
-				EncryptionKey: *encryptionKey,
-				Created:       util.TimestampToTime(int64(metadata.Created)),
-				LastModified:  util.TimestampToTime(int64(metadata.LastModified)),
-				ParentUUID:    file.Parent,
-			},
-			Size:      metadata.Size,
-			Favorited: file.Favorited == 1,
-			Region:    file.Region,
-			Bucket:    file.Bucket,
-			Chunks:    file.Chunks,
-			Hash:      metadata.Hash,
-		})
+		files = append(files, f)
 	}
 
 	// transform directories
 	directories := make([]*types.Directory, 0)
 	for _, directory := range directoryContent.Folders {
-		metaStr, err := api.DecryptMeta(directory.Metadata)
+		d, err := api.newDirectory(directory.UUID, directory.Parent, directory.Metadata, directory.Color, directory.Timestamp, directory.Favorited == 1)
 		if err != nil {
-			return nil, nil, fmt.Errorf("ReadDirectory decrypting metadata: %v", err)
+			if err = handleItemError(directory.UUID, true, err); err != nil {
+				return nil, nil, err
+			}
+			continue
 		}
-		metaData := types.DirectoryMetaData{}
-		err = json.Unmarshal([]byte(metaStr), &metaData)
+		directories = append(directories, d)
+	}
+
+	return files, directories, nil
+}
+
+// readDirectoryTree lists all files and directories below the directory with the given UUID with a single request.
+func (api *Filen) readDirectoryTree(ctx context.Context, uuid string, opts ReadDirectoryOptions) ([]*types.File, []*types.Directory, error) {
+	response, err := api.Client.PostV3DirDownload(ctx, uuid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read directory tree: %w", err)
+	}
+
+	files := make([]*types.File, 0, len(response.Files))
+	for _, file := range response.Files {
+		f, err := api.newFile(file.UUID, file.Parent, file.Metadata, file.Region, file.Bucket, file.Chunks, file.Favorited == 1)
 		if err != nil {
-			return nil, nil, fmt.Errorf("ReadDirectory unmarshalling metadata: %v", err)
+			if err = opts.itemError(file.UUID, false, err); err != nil {
+				return nil, nil, fmt.Errorf("read directory tree: %w", err)
+			}
+			continue
 		}
+		files = append(files, f)
+	}
 
-		creationTimestamp := metaData.Creation
-		if creationTimestamp == 0 {
-			creationTimestamp = directory.Timestamp
+	directories := make([]*types.Directory, 0, len(response.Folders))
+	for _, directory := range response.Folders {
+		if directory.UUID == uuid {
+			continue
 		}
-
-		directories = append(directories, &types.Directory{
-			UUID:       directory.UUID,
-			Name:       metaData.Name,
-			ParentUUID: directory.Parent,
-			Color:      directory.Color,
-			Created:    util.TimestampToTime(int64(creationTimestamp)),
-			Favorited:  directory.Favorited == 1,
-		})
+		d, err := api.newDirectory(directory.UUID, directory.Parent, directory.Metadata, directory.Color, directory.Timestamp, directory.Favorited == 1)
+		if err != nil {
+			if err = opts.itemError(directory.UUID, true, err); err != nil {
+				return nil, nil, fmt.Errorf("read directory tree: %w", err)
+			}
+			continue
+		}
+		directories = append(directories, d)
 	}
-
 	return files, directories, nil
 }
 
@@ -201,20 +364,23 @@ func (api *Filen) TrashFile(ctx context.Context, file types.File) error {
 	return api.Client.PostV3FileTrash(ctx, file.GetUUID())
 }
 
-// CreateDirectory creates a new directory.
+// CreateDirectory creates a new directory. An existing directory with the same name is handled
+// according to the conflict policy, see [WithConflictPolicy].
 func (api *Filen) CreateDirectory(ctx context.Context, parent types.DirectoryInterface, name string) (*types.Directory, error) {
+	name, existing, err := api.resolveConflict(ctx, parent.GetUUID(), name, true)
+	if err != nil {
+		return nil, err
+	}
+	if existing != "" {
+		return api.getChildDirectory(ctx, existing)
+	}
 	directoryUUID := uuid.New().String()
 	creationTime := time.Now().Round(time.Millisecond)
 	// encrypt metadata
-	metadata := types.DirectoryMetaData{
-		Name:     name,
-		Creation: int(creationTime.UnixMilli()),
-	}
-	metadataStr, err := json.Marshal(metadata)
+	metadataEncrypted, err := api.encryptDirectoryMetadata(name, creationTime)
 	if err != nil {
 		return nil, err
 	}
-	metadataEncrypted := api.EncryptMeta(string(metadataStr))
 
 	// hash name
 	nameHashed := api.HashFileName(name)
@@ -224,17 +390,99 @@ func (api *Filen) CreateDirectory(ctx context.Context, parent types.DirectoryInt
 	if err != nil {
 		return nil, err
 	}
-	return &types.Directory{
+	if response.UUID != directoryUUID {
+		// the directory already existed
+		return api.getChildDirectory(ctx, response.UUID)
+	}
+	directory := &types.Directory{
 		UUID:       response.UUID,
 		Name:       name,
 		ParentUUID: parent.GetUUID(),
 		Color:      types.DirColorDefault,
 		Created:    creationTime,
 		Favorited:  false,
-	}, nil
+	}
+	api.pathCache.putChild(directory)
+	return directory, nil
+}
+
+// getChildDirectory fetches an existing directory and adds it to the path cache.
+func (api *Filen) getChildDirectory(ctx context.Context, uuid string) (*types.Directory, error) {
+	directory, err := api.GetDirectory(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+	api.pathCache.putChild(directory)
+	return directory, nil
 }
 
 // TrashDirectory moves a directory to trash.
 func (api *Filen) TrashDirectory(ctx context.Context, dir types.DirectoryInterface) error {
-	return api.Client.PostV3DirTrash(ctx, dir.GetUUID())
+	err := api.Client.PostV3DirTrash(ctx, dir.GetUUID())
+	if err != nil {
+		return err
+	}
+	api.pathCache.removeDirectory(dir.GetUUID())
+	return nil
+}
+
+// MoveFile moves a file to another directory and returns the moved file.
+func (api *Filen) MoveFile(ctx context.Context, file *types.File, newParent types.DirectoryInterface) (*types.File, error) {
+	err := api.Client.PostV3FileMove(ctx, file.UUID, newParent.GetUUID())
+	if err != nil {
+		return nil, fmt.Errorf("move file: %w", err)
+	}
+	moved := *file
+	moved.ParentUUID = newParent.GetUUID()
+	return &moved, nil
+}
+
+// MoveDirectory moves a directory (including its contents) to another directory and returns the moved directory.
+func (api *Filen) MoveDirectory(ctx context.Context, dir *types.Directory, newParent types.DirectoryInterface) (*types.Directory, error) {
+	err := api.Client.PostV3DirMove(ctx, dir.UUID, newParent.GetUUID())
+	if err != nil {
+		return nil, fmt.Errorf("move directory: %w", err)
+	}
+	moved := *dir
+	moved.ParentUUID = newParent.GetUUID()
+	api.pathCache.removeDirectory(dir.UUID)
+	api.pathCache.putChild(&moved)
+	return &moved, nil
+}
+
+// RenameFile renames a file and returns the renamed file.
+func (api *Filen) RenameFile(ctx context.Context, file *types.File, newName string) (*types.File, error) {
+	renamed := *file
+	renamed.Name = newName
+	metadataEncrypted, err := api.encryptFileMetadata(&renamed)
+	if err != nil {
+		return nil, err
+	}
+	nameEncrypted := renamed.EncryptionKey.EncryptMeta(newName)
+	nameHashed := api.HashFileName(newName)
+
+	err = api.Client.PostV3FileRename(ctx, renamed.UUID, nameEncrypted, nameHashed, metadataEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("rename file: %w", err)
+	}
+	return &renamed, nil
+}
+
+// RenameDirectory renames a directory and returns the renamed directory.
+func (api *Filen) RenameDirectory(ctx context.Context, dir *types.Directory, newName string) (*types.Directory, error) {
+	metadataEncrypted, err := api.encryptDirectoryMetadata(newName, dir.Created)
+	if err != nil {
+		return nil, err
+	}
+	nameHashed := api.HashFileName(newName)
+
+	err = api.Client.PostV3DirRename(ctx, dir.UUID, metadataEncrypted, nameHashed)
+	if err != nil {
+		return nil, fmt.Errorf("rename directory: %w", err)
+	}
+	renamed := *dir
+	renamed.Name = newName
+	api.pathCache.removeDirectory(dir.UUID)
+	api.pathCache.putChild(&renamed)
+	return &renamed, nil
 }
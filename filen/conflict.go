@@ -0,0 +1,150 @@
+package filen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+	"path"
+	"strings"
+)
+
+// A ConflictPolicy decides what happens when a file is uploaded or a directory is created while
+// the parent directory already contains a file or directory (respectively) with the same name.
+// Like on the server, names are compared case-insensitively.
+type ConflictPolicy int
+
+const (
+	// ConflictOverwrite uploads a file as a new version of the existing file,
+	// and returns the existing directory (as fetched from the server) instead of creating one. This is the default.
+	ConflictOverwrite ConflictPolicy = iota
+	// ConflictFail fails with [ErrExists].
+	ConflictFail
+	// ConflictRename uploads or creates the item with the first unused name of the form "name (1).ext".
+	ConflictRename
+	// ConflictSkip returns the existing item without uploading or creating anything.
+	// Existing files are fetched with [Filen.GetFile], so their Favorited field is not populated.
+	ConflictSkip
+)
+
+// ErrExists is returned if an item already exists and the [ConflictPolicy] is ConflictFail.
+var ErrExists = errors.New("item already exists")
+
+// maxConflictRenames is how many names ConflictRename tries before giving up.
+const maxConflictRenames = 1000
+
+type conflictPolicyKey struct{}
+
+// WithConflictPolicy returns a context which selects the conflict policy for uploads and directory creation.
+func WithConflictPolicy(ctx context.Context, policy ConflictPolicy) context.Context {
+	return context.WithValue(ctx, conflictPolicyKey{}, policy)
+}
+
+func conflictPolicyFor(ctx context.Context) ConflictPolicy {
+	policy, _ := ctx.Value(conflictPolicyKey{}).(ConflictPolicy)
+	return policy
+}
+
+// FileExists reports whether parent contains a file with the given name, ignoring case.
+func (api *Filen) FileExists(ctx context.Context, parent types.DirectoryInterface, name string) (bool, error) {
+	uuid, err := api.existingItem(ctx, parent.GetUUID(), name, false)
+	return uuid != "", err
+}
+
+// DirectoryExists reports whether parent contains a directory with the given name, ignoring case.
+func (api *Filen) DirectoryExists(ctx context.Context, parent types.DirectoryInterface, name string) (bool, error) {
+	uuid, err := api.existingItem(ctx, parent.GetUUID(), name, true)
+	return uuid != "", err
+}
+
+// existingItem returns the UUID of the file or directory with the given name in parent, or "" if there is none.
+func (api *Filen) existingItem(ctx context.Context, parent string, name string, directory bool) (string, error) {
+	nameHashed := api.HashFileName(name)
+	if directory {
+		response, err := api.Client.PostV3DirExists(ctx, parent, nameHashed)
+		if err != nil {
+			return "", fmt.Errorf("check directory exists: %w", err)
+		}
+		if !response.Exists {
+			return "", nil
+		}
+		return response.UUID, nil
+	}
+	response, err := api.Client.PostV3FileExists(ctx, parent, nameHashed)
+	if err != nil {
+		return "", fmt.Errorf("check file exists: %w", err)
+	}
+	if !response.Exists {
+		return "", nil
+	}
+	return response.UUID, nil
+}
+
+// conflictName returns the n-th alternative name for ConflictRename, e.g. "name (n).ext".
+func conflictName(name string, n int) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		// e.g. ".bashrc"
+		base, ext = name, ""
+	}
+	return fmt.Sprintf("%s (%d)%s", base, n, ext)
+}
+
+// resolveConflict applies the conflict policy from ctx to a new item with the given name in parent.
+// It returns the name to use, or with ConflictSkip the UUID of the existing item to return instead.
+// ConflictOverwrite is left to the server, which stores an upload as a new version of the existing file
+// and responds to the creation of an existing directory with its UUID.
+func (api *Filen) resolveConflict(ctx context.Context, parent string, name string, directory bool) (string, string, error) {
+	policy := conflictPolicyFor(ctx)
+	if policy == ConflictOverwrite {
+		return name, "", nil
+	}
+	existing, err := api.existingItem(ctx, parent, name, directory)
+	if err != nil || existing == "" {
+		return name, "", err
+	}
+	switch policy {
+	case ConflictFail:
+		return "", "", fmt.Errorf("%s: %w", name, ErrExists)
+	case ConflictSkip:
+		return "", existing, nil
+	case ConflictRename:
+		for n := 1; n <= maxConflictRenames; n++ {
+			candidate := conflictName(name, n)
+			existing, err = api.existingItem(ctx, parent, candidate, directory)
+			if err != nil {
+				return "", "", err
+			}
+			if existing == "" {
+				return candidate, "", nil
+			}
+		}
+		return "", "", fmt.Errorf("%s: no unused name found: %w", name, ErrExists)
+	default:
+		return "", "", fmt.Errorf("invalid conflict policy %d", policy)
+	}
+}
+
+// resolveFileConflict applies the conflict policy from ctx to a file to be uploaded.
+// It returns the file to upload, which is a renamed copy with ConflictRename,
+// or with ConflictSkip the existing file to return instead.
+func (api *Filen) resolveFileConflict(ctx context.Context, file *types.IncompleteFile) (*types.IncompleteFile, *types.File, error) {
+	name, existing, err := api.resolveConflict(ctx, file.ParentUUID, file.Name, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing != "" {
+		existingFile, err := api.GetFile(ctx, existing)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, existingFile, nil
+	}
+	if name != file.Name {
+		renamed := *file
+		renamed.Name = name
+		file = &renamed
+	}
+	return file, nil, nil
+}
@@ -1,6 +1,7 @@
 package filen
 
 const (
-	ChunkSize    = 1024 * 1024
+	ChunkSize = 1024 * 1024
+	// MaxUploaders is the default number of chunks uploaded concurrently, see [TransferOptions]
 	MaxUploaders = 16
 )
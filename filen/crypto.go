@@ -4,6 +4,7 @@ import (
 	"crypto/sha1"
 	"crypto/sha512"
 	"encoding/hex"
+	"fmt"
 	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
 	"strings"
 )
@@ -22,29 +23,33 @@ func (api *Filen) HashFileName(name string) string {
 	}
 }
 
+// EncryptMeta encrypts metadata for the current user. v1 accounts use the same "002" format as v2 accounts,
+// which every Filen client can read; the deprecated v1 format is only ever decrypted.
 func (api *Filen) EncryptMeta(metadata string) crypto.EncryptedString {
 	switch api.AuthVersion {
-	case 1:
-		panic("todo")
-	case 2:
+	case 1, 2:
 		return api.MasterKeys.EncryptMeta(metadata)
-	case 3:
-		return api.DEK.EncryptMeta(metadata)
 	default:
-		panic("unsupported version")
+		return api.DEK.EncryptMeta(metadata)
 	}
 }
 
+// DecryptMeta decrypts metadata of any format version. Invalid input results in an error wrapping
+// [crypto.ErrMalformedCiphertext] or [crypto.ErrUnsupportedFormat].
 func (api *Filen) DecryptMeta(encrypted crypto.EncryptedString) (string, error) {
-	if encrypted[0:8] == "U2FsdGVk" {
-		return api.MasterKeys.DecryptMetaV1(encrypted)
+	version, err := crypto.MetadataVersion(encrypted)
+	if err != nil {
+		return "", err
 	}
-	switch encrypted[0:3] {
-	case "002":
+	switch version {
+	case 1:
+		return api.MasterKeys.DecryptMetaV1(encrypted)
+	case 2:
 		return api.MasterKeys.DecryptMetaV2(encrypted)
-	case "003":
-		return api.DEK.DecryptMeta(encrypted)
 	default:
-		panic("unsupported version")
+		if api.AuthVersion < 3 {
+			return "", fmt.Errorf("metadata version 3 for auth version %d: %w", api.AuthVersion, crypto.ErrUnsupportedFormat)
+		}
+		return api.DEK.DecryptMeta(encrypted)
 	}
 }
@@ -47,12 +47,11 @@ type MasterKeys []MasterKey
 func NewMasterKeys(encryptionKey MasterKey, stringKeys string) (MasterKeys, error) {
 	keys := make([]MasterKey, 0)
 	for _, key := range strings.Split(stringKeys, "|") {
-		if len(key) != 64 {
+		// v1 master keys are 40 characters long, v2 master keys 64
+		if len(key) == 0 {
 			return nil, fmt.Errorf("key length wrong %d", len(key))
 		}
-		keyBytes := []byte(key)
-		keySized := [64]byte(keyBytes)
-		mk, err := NewMasterKey(keySized)
+		mk, err := NewMasterKeyFromString(key)
 		if err != nil {
 			return nil, fmt.Errorf("NewMasterKey: %w", err)
 		}
@@ -80,34 +79,57 @@ func getCipherForKey(key [32]byte) (cipher.AEAD, error) {
 // DecryptMeta should be avoided, and Filen.DecryptMeta should be used instead,
 // but this is necessary for RSA Keypair decryption
 func (ms *MasterKeys) DecryptMeta(encrypted EncryptedString) (string, error) {
-	if encrypted[0:8] == "U2FsdGVk" {
-		return ms.DecryptMetaV1(encrypted)
+	version, err := MetadataVersion(encrypted)
+	if err != nil {
+		return "", err
 	}
-	if encrypted[0:3] == "002" {
+	switch version {
+	case 1:
+		return ms.DecryptMetaV1(encrypted)
+	case 2:
 		return ms.DecryptMetaV2(encrypted)
+	default:
+		return "", fmt.Errorf("metadata version %d cannot be decrypted with master keys: %w", version, ErrUnsupportedFormat)
 	}
-	return "", fmt.Errorf("unknown metadata format")
 }
 
 type MasterKey struct {
-	Bytes        [64]byte
+	Bytes        [64]byte // the key, zero-padded if it is shorter (v1 keys), see [MasterKey.Key]
 	DerivedBytes [32]byte
 	cipher       cipher.AEAD
+	key          string
 }
 
 func NewMasterKey(key [64]byte) (*MasterKey, error) {
-	derivedKey := pbkdf2.Key(key[:], key[:], 1, 32, sha512.New)
+	return NewMasterKeyFromString(string(key[:]))
+}
+
+// NewMasterKeyFromString creates a master key of any length up to 64 bytes, which is needed for v1 master keys.
+func NewMasterKeyFromString(key string) (*MasterKey, error) {
+	if len(key) > 64 {
+		return nil, fmt.Errorf("master key has %d bytes, at most 64 are supported", len(key))
+	}
+	keyBytes := []byte(key)
+	derivedKey := pbkdf2.Key(keyBytes, keyBytes, 1, 32, sha512.New)
 	derivedBytes := [32]byte{}
 	copy(derivedBytes[:], derivedKey[:32])
 	c, err := getCipherForKey(derivedBytes)
 	if err != nil {
-		return nil, fmt.Errorf("NewMasterKey: %v", err)
+		return nil, fmt.Errorf("NewMasterKey: %w", err)
 	}
-	return &MasterKey{
-		Bytes:        key,
+	masterKey := &MasterKey{
 		DerivedBytes: derivedBytes,
 		cipher:       c,
-	}, nil
+		key:          key,
+	}
+	copy(masterKey.Bytes[:], keyBytes)
+	return masterKey, nil
+}
+
+// Key returns the key as a string: 40 hex characters for v1, 64 alphanumerical characters for v2.
+// Unlike Bytes, it is not padded.
+func (m *MasterKey) Key() string {
+	return m.key
 }
 
 func (m *MasterKey) EncryptMeta(metadata string) EncryptedString {
@@ -116,15 +138,23 @@ func (m *MasterKey) EncryptMeta(metadata string) EncryptedString {
 	return NewEncryptedStringV2(encrypted, nonce)
 }
 
+// DecryptMetaV1 decrypts metadata in the deprecated OpenSSL-compatible ("Salted__", base64 "U2FsdGVk") format,
+// which uses the master key itself as the passphrase.
 func (m *MasterKey) DecryptMetaV1(metadata EncryptedString) (string, error) {
 	decoded, err := base64.StdEncoding.DecodeString(string(metadata))
 	if err != nil {
-		return "", fmt.Errorf("failed to decode base64: %w", err)
+		return "", malformed("failed to decode base64: %v", err)
+	}
+	if len(decoded) < 16+aes.BlockSize || string(decoded[:8]) != "Salted__" {
+		return "", malformed("metadata too short or missing salt")
 	}
 	salt := decoded[8:16]
 	cipherText := decoded[16:]
+	if len(cipherText)%aes.BlockSize != 0 {
+		return "", malformed("ciphertext length %d is not a multiple of the block size", len(cipherText))
+	}
 
-	keyBytes, ivBytes := deriveKeyAndIV(m.DerivedBytes[:], salt, 32, 16)
+	keyBytes, ivBytes := deriveKeyAndIV([]byte(m.key), salt, 32, 16)
 
 	block, err := aes.NewCipher(keyBytes)
 	if err != nil {
@@ -138,34 +168,40 @@ func (m *MasterKey) DecryptMetaV1(metadata EncryptedString) (string, error) {
 
 	paddingLen := int(plaintext[len(plaintext)-1])
 	if paddingLen > aes.BlockSize || paddingLen <= 0 {
-		return "", fmt.Errorf("invalid padding size")
+		return "", malformed("invalid padding size")
 	}
 
 	return string(plaintext[:len(plaintext)-paddingLen]), nil
 }
 
 func (m *MasterKey) DecryptMetaV2(metadata EncryptedString) (string, error) {
+	if len(metadata) < 15 {
+		return "", malformed("DecryptMetadataV2: metadata too short")
+	}
 	nonce := metadata[3:15]
 	decoded, err := base64.StdEncoding.DecodeString(string(metadata[15:]))
 	if err != nil {
-		return "", fmt.Errorf("DecryptMetadataV2: %v", err)
+		return "", malformed("DecryptMetadataV2: %v", err)
 	}
 	decoded, err = m.cipher.Open(decoded[:0], []byte(nonce), decoded, nil)
 	if err != nil {
-		return "", fmt.Errorf("DecryptMetadataV2: %v", err)
+		return "", malformed("DecryptMetadataV2: %v", err)
 	}
 	return string(decoded), nil
 }
 
 func (m *MasterKey) DecryptMeta(metadata EncryptedString) (string, error) {
-	if metadata[0:8] == "U2FsdGVk" {
-		return m.DecryptMetaV1(metadata)
+	version, err := MetadataVersion(metadata)
+	if err != nil {
+		return "", err
 	}
-	switch metadata[0:3] {
-	case "002":
+	switch version {
+	case 1:
+		return m.DecryptMetaV1(metadata)
+	case 2:
 		return m.DecryptMetaV2(metadata)
 	default:
-		return "", fmt.Errorf("unknown metadata format")
+		return "", fmt.Errorf("metadata version %d cannot be decrypted with a master key: %w", version, ErrUnsupportedFormat)
 	}
 }
 
@@ -178,6 +214,10 @@ func (e *AllKeysFailedError) Error() string {
 	return fmt.Sprintf("all keys failed: %v", e.Errors)
 }
 
+func (e *AllKeysFailedError) Unwrap() []error {
+	return e.Errors
+}
+
 func (ms *MasterKeys) decryptMeta(metadata EncryptedString, decryptFunc func(m *MasterKey, encryptedString EncryptedString) (string, error)) (string, error) {
 	errs := make([]error, 0)
 	for _, masterKey := range *ms {
@@ -228,6 +268,15 @@ func DeriveMKAndAuthFromPassword(password string, salt string) (*MasterKey, Deri
 	return masterKey, derivedPass, nil
 }
 
+// DeriveMKAndAuthFromPasswordV1 derives the master key and the password sent to the API for v1 accounts.
+func DeriveMKAndAuthFromPasswordV1(password string) (*MasterKey, DerivedPassword, error) {
+	masterKey, err := NewMasterKeyFromString(hashV1(password))
+	if err != nil {
+		return nil, "", fmt.Errorf("NewMasterKey: %w", err)
+	}
+	return masterKey, DerivedPassword(hashPasswordV1(password)), nil
+}
+
 // v3
 
 type EncryptionKey struct {
@@ -260,20 +309,25 @@ func (key *EncryptionKey) EncryptMeta(metadata string) EncryptedString {
 }
 
 func (key *EncryptionKey) DecryptMeta(metadata EncryptedString) (string, error) {
-	if metadata[0:3] != "003" {
-		return "", fmt.Errorf("unsupported metadata %s format (allowed: 003)", metadata[0:3])
+	if version, err := MetadataVersion(metadata); err != nil {
+		return "", err
+	} else if version != 3 {
+		return "", fmt.Errorf("metadata version %d (allowed: 3): %w", version, ErrUnsupportedFormat)
+	}
+	if len(metadata) < 27 {
+		return "", malformed("metadata too short")
 	}
 	nonce, err := hex.DecodeString(string(metadata[3:27]))
 	if err != nil {
-		return "", fmt.Errorf("decoding nonce: %v", err)
+		return "", malformed("decoding nonce: %v", err)
 	}
 	decoded, err := base64.StdEncoding.DecodeString(string(metadata[27:]))
 	if err != nil {
-		return "", fmt.Errorf("decoding metadata: %v", err)
+		return "", malformed("decoding metadata: %v", err)
 	}
 	decrypted, err := key.Cipher.Open(nil, nonce[:], decoded, nil)
 	if err != nil {
-		return "", fmt.Errorf("decrypting: %v", err)
+		return "", malformed("decrypting: %v", err)
 	}
 	return string(decrypted), nil
 }
@@ -292,7 +346,10 @@ func MakeEncryptionKeyFromBytes(key [32]byte) (*EncryptionKey, error) {
 func MakeEncryptionKeyFromStr(key string) (*EncryptionKey, error) {
 	decoded, err := hex.DecodeString(key)
 	if err != nil {
-		return nil, fmt.Errorf("decoding DEK: %w", err)
+		return nil, malformed("decoding key: %v", err)
+	}
+	if len(decoded) != 32 {
+		return nil, fmt.Errorf("key length %d (expected 32): %w", len(decoded), ErrUnsupportedFormat)
 	}
 	return MakeEncryptionKeyFromBytes([32]byte(decoded))
 }
@@ -324,7 +381,7 @@ func MakeEncryptionKeyFromUnknownStr(key string) (*EncryptionKey, error) {
 	case 64: // v3
 		return MakeEncryptionKeyFromStr(key)
 	default:
-		return nil, fmt.Errorf("key length wrong")
+		return nil, fmt.Errorf("key length %d: %w", len(key), ErrUnsupportedFormat)
 	}
 }
 
@@ -341,12 +398,15 @@ func (key *EncryptionKey) EncryptData(data []byte) []byte {
 func (key *EncryptionKey) decrypt(nonce []byte, data []byte) error {
 	data, err := key.Cipher.Open(data[:0], nonce, data, nil)
 	if err != nil {
-		return fmt.Errorf("open: %v", err)
+		return malformed("open: %v", err)
 	}
 	return nil
 }
 
 func (key *EncryptionKey) DecryptData(data []byte) ([]byte, error) {
+	if len(data) < 12+key.Cipher.Overhead() {
+		return nil, malformed("data too short (%d bytes)", len(data))
+	}
 	nonce := data[:12]
 	err := key.decrypt(nonce, data[12:])
 	if err != nil {
@@ -365,30 +425,30 @@ func (key *EncryptionKey) ToStringWithAuthVersion(authVersion int) string {
 func RSAKeyPairFromStrings(privKey string, pubKey string) (*rsa.PrivateKey, *rsa.PublicKey, error) {
 	publicKeyDecoded, err := base64.StdEncoding.DecodeString(pubKey)
 	if err != nil {
-		return nil, nil, fmt.Errorf("decoding public key: %v", err)
+		return nil, nil, malformed("decoding public key: %v", err)
 	}
 	privateKeyDecoded, err := base64.StdEncoding.DecodeString(privKey)
 	if err != nil {
-		return nil, nil, fmt.Errorf("decoding private key: %v", err)
+		return nil, nil, malformed("decoding private key: %v", err)
 	}
 	publicKeyAny, err := x509.ParsePKIXPublicKey(publicKeyDecoded)
 	if err != nil {
-		return nil, nil, fmt.Errorf("parsing public key: %v", err)
+		return nil, nil, malformed("parsing public key: %v", err)
 	}
 
 	publicKey, ok := publicKeyAny.(*rsa.PublicKey)
 	if !ok {
-		return nil, nil, fmt.Errorf("parsing public key, failed to cast: %v", err)
+		return nil, nil, fmt.Errorf("parsing public key, %T is not an RSA key: %w", publicKeyAny, ErrUnsupportedFormat)
 	}
 
 	privateKeyAny, err := x509.ParsePKCS8PrivateKey(privateKeyDecoded)
 	if err != nil {
-		return nil, nil, fmt.Errorf("parsing private key: %v", err)
+		return nil, nil, malformed("parsing private key: %v", err)
 	}
 
 	privateKey, ok := privateKeyAny.(*rsa.PrivateKey)
 	if !ok {
-		return nil, nil, fmt.Errorf("parsing private key, failed to cast: %v", err)
+		return nil, nil, fmt.Errorf("parsing private key, %T is not an RSA key: %w", privateKeyAny, ErrUnsupportedFormat)
 	}
 
 	if !publicKey.Equal(&privateKey.PublicKey) {
@@ -0,0 +1,34 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors for input which cannot be decrypted or parsed, for use with errors.Is.
+var (
+	ErrMalformedCiphertext = errors.New("malformed ciphertext") // the input is truncated, badly encoded, or fails authentication with the key
+	ErrUnsupportedFormat   = errors.New("unsupported format")   // the input has an unknown format version, key length or key type
+)
+
+// malformed returns an error wrapping ErrMalformedCiphertext.
+func malformed(format string, args ...any) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), ErrMalformedCiphertext)
+}
+
+// MetadataVersion returns the format version of encrypted metadata: 1 for the deprecated OpenSSL-compatible
+// format (base64 "U2FsdGVk"), and 2 or 3 for metadata prefixed with "002" or "003".
+func MetadataVersion(metadata EncryptedString) (int, error) {
+	switch {
+	case strings.HasPrefix(string(metadata), "U2FsdGVk"):
+		return 1, nil
+	case strings.HasPrefix(string(metadata), "002"):
+		return 2, nil
+	case strings.HasPrefix(string(metadata), "003"):
+		return 3, nil
+	default:
+		prefix := metadata[:min(len(metadata), 3)]
+		return 0, fmt.Errorf("metadata format %q: %w", prefix, ErrUnsupportedFormat)
+	}
+}
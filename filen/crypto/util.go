@@ -3,7 +3,12 @@ package crypto
 import (
 	"crypto/md5"
 	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/hex"
+	"golang.org/x/crypto/md4"
+	"hash"
 	"math/big"
 )
 
@@ -58,3 +63,23 @@ func deriveKeyAndIV(key, salt []byte, keyLen, ivLen int) ([]byte, []byte) {
 
 	return keyAndIV[:keyLen], keyAndIV[keyLen:]
 }
+
+// hexHash returns the hex-encoded digest of data.
+func hexHash(newHash func() hash.Hash, data string) string {
+	hasher := newHash()
+	hasher.Write([]byte(data))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// hashV1 is the hash used to derive v1 master keys (and to hash v1 and v2 file names).
+func hashV1(data string) string {
+	return hexHash(sha1.New, hexHash(sha512.New, data))
+}
+
+// hashPasswordV1 derives the password sent to the API for v1 accounts.
+func hashPasswordV1(password string) string {
+	md2Digest := md2Sum([]byte(password))
+	md4Hex := hexHash(md4.New, hex.EncodeToString(md2Digest[:]))
+	return hexHash(sha512.New, hexHash(sha512.New384, hexHash(sha256.New, hexHash(sha1.New, password)))) +
+		hexHash(sha512.New, hexHash(md5.New, md4Hex))
+}
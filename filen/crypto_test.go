@@ -1,6 +1,10 @@
 package filen
 
-import "testing"
+import (
+	"errors"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
+	"testing"
+)
 
 func TestHashFileName(t *testing.T) {
 	api := Filen{
@@ -17,3 +21,89 @@ func TestHashFileName(t *testing.T) {
 		}
 	}
 }
+
+func TestDeriveMKAndAuthFromPasswordV1(t *testing.T) {
+	masterKey, derivedPass, err := crypto.DeriveMKAndAuthFromPasswordV1("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if masterKey.Key() != "5c5a4ad792911a5a58741e16257f62b664aa2df3" {
+		t.Errorf("unexpected master key %s", masterKey.Key())
+	}
+	expectedPass := "e5457ca019327323dbfe3c150ff3a70c62fa04b6132ac3c152dbedb397874c3cc07a98517a8c1768c36aec6ee5c404" +
+		"024cfc6b181421a4eb77110f677f17f395e136d38e45b90c86ebec3815324e357821508013a1b2c728483972df53c9dd51393680a10d" +
+		"78da8f1045dfcb6ba24ed5f6c6b4cca0a39dcfa29cca35f5c35d5a"
+	if string(derivedPass) != expectedPass {
+		t.Errorf("unexpected derived password %s", derivedPass)
+	}
+}
+
+func TestDecryptMetaV1(t *testing.T) {
+	// generated with `openssl enc -aes-256-cbc -md md5`, which is what CryptoJS.AES.encrypt produces
+	masterKey, err := crypto.NewMasterKeyFromString("0123456789abcdef0123456789abcdef01234567")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := Filen{
+		AuthVersion: 1,
+		MasterKeys:  crypto.MasterKeys{*masterKey},
+	}
+	decrypted, err := api.DecryptMeta("U2FsdGVkX18BAgMEBQYHCD3Nqgud5wkt7XSG/+wMfcc=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted != "hello v1" {
+		t.Errorf("expected hello v1, got %s", decrypted)
+	}
+
+	// new metadata is encrypted in a format which v1 accounts can read
+	encrypted := api.EncryptMeta("metadata")
+	if decrypted, err = api.DecryptMeta(encrypted); err != nil || decrypted != "metadata" {
+		t.Errorf("failed to round trip metadata: %q, %v", decrypted, err)
+	}
+}
+
+func TestDecryptMalformed(t *testing.T) {
+	masterKey, _, err := crypto.DeriveMKAndAuthFromPasswordV1("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dek, err := crypto.NewEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := Filen{
+		AuthVersion: 3,
+		MasterKeys:  crypto.MasterKeys{*masterKey},
+		DEK:         *dek,
+	}
+	inputs := map[crypto.EncryptedString]error{
+		"":                                crypto.ErrUnsupportedFormat,
+		"00":                              crypto.ErrUnsupportedFormat,
+		"004abc":                          crypto.ErrUnsupportedFormat,
+		"U2FsdGVk":                        crypto.ErrMalformedCiphertext,
+		"U2FsdGVkX18BAgMEBQ==":            crypto.ErrMalformedCiphertext,
+		"002":                             crypto.ErrMalformedCiphertext,
+		"002abcdefghijkl!!":               crypto.ErrMalformedCiphertext,
+		"003":                             crypto.ErrMalformedCiphertext,
+		"003zz":                           crypto.ErrMalformedCiphertext,
+		"003000000000000000000000000AAAA": crypto.ErrMalformedCiphertext,
+	}
+	for input, expected := range inputs {
+		if _, err := api.DecryptMeta(input); !errors.Is(err, expected) {
+			t.Errorf("decrypting %q: expected %v, got %v", input, expected, err)
+		}
+	}
+
+	for _, data := range [][]byte{nil, make([]byte, 12), make([]byte, 40)} {
+		if _, err := dek.DecryptData(data); !errors.Is(err, crypto.ErrMalformedCiphertext) {
+			t.Errorf("decrypting %d bytes: expected malformed ciphertext, got %v", len(data), err)
+		}
+	}
+	if _, _, err := crypto.RSAKeyPairFromStrings("!!", "!!"); !errors.Is(err, crypto.ErrMalformedCiphertext) {
+		t.Errorf("expected malformed ciphertext for invalid RSA keys, got %v", err)
+	}
+	if _, err := crypto.MakeEncryptionKeyFromUnknownStr("abc"); !errors.Is(err, crypto.ErrUnsupportedFormat) {
+		t.Errorf("expected unsupported format for short key, got %v", err)
+	}
+}
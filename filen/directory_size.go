@@ -0,0 +1,51 @@
+package filen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/client"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+	"net/http"
+)
+
+// DirectoryStats describes the contents of a directory, including subdirectories.
+type DirectoryStats struct {
+	Size        int64 // the total size of all files in bytes
+	Files       int   // the number of files
+	Directories int   // the number of directories, not counting the directory itself
+}
+
+// DirectorySize returns the total size and the number of files and directories below dir.
+// The statistics are computed by the server. If the server fails to compute them or does not support it,
+// they are aggregated from a listing of the directory tree instead, see [Filen.ReadDirectoryTree] and [DirectoryTree.Stats].
+// Other errors, e.g. [client.ErrNotFound] for a missing directory, are returned directly.
+func (api *Filen) DirectorySize(ctx context.Context, dir types.DirectoryInterface) (DirectoryStats, error) {
+	response, err := api.Client.PostV3DirSize(ctx, dir.GetUUID())
+	if err == nil {
+		return DirectoryStats{Size: response.Size, Files: response.Files, Directories: response.Folders}, nil
+	}
+	if ctx.Err() != nil || !dirSizeUnavailable(err) {
+		return DirectoryStats{}, fmt.Errorf("directory size: %w", err)
+	}
+	tree, treeErr := api.ReadDirectoryTree(ctx, dir)
+	if treeErr != nil {
+		return DirectoryStats{}, fmt.Errorf("directory size: %w (listing the directory tree failed as well: %w)", err, treeErr)
+	}
+	return tree.Stats(), nil
+}
+
+// dirSizeUnavailable reports whether err means that the server cannot compute the size of a directory,
+// as opposed to a problem with the directory, the session or the connection, which a listing would run into as well.
+func dirSizeUnavailable(err error) bool {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, sentinel := range []error{client.ErrNotFound, client.ErrUnauthorized, client.ErrRateLimited} {
+		if errors.Is(err, sentinel) {
+			return false
+		}
+	}
+	return apiErr.HTTPStatus >= http.StatusInternalServerError || apiErr.HTTPStatus == http.StatusMethodNotAllowed
+}
@@ -26,7 +26,7 @@ func (api *Filen) fetchAndDecryptChunk(ctx context.Context, file *types.File, ch
 
 // Configurable constants
 const (
-	// MaxBufferSize is the number of chunks to keep in memory
+	// MaxBufferSize is the default number of chunks a download reader keeps in memory, see [TransferOptions]
 	MaxBufferSize = 8
 )
 
@@ -107,6 +107,9 @@ type ChunkedReader struct {
 	lastChunkIndex    int
 	lastOffsetInChunk int
 	totalRead         int // -1 if we started with an offset
+	memory            *memoryBudget
+	releaseOnce       sync.Once
+	progress          *progressTracker
 }
 
 // newChunkedReader creates a new ChunkedReader for sequential reading
@@ -131,12 +134,32 @@ func newChunkedReaderWithOffset(ctx context.Context, api *Filen, file *types.Fil
 		lastOffsetInChunk = limit % ChunkSize
 	}
 
-	if lastOffsetInChunk == 0 && lastChunkIndex == file.Chunks-1 {
+	if lastOffsetInChunk == 0 {
+		// the limit is at a chunk boundary, so the previous chunk is the last one to read
+		// (unless lastChunkIndex was already capped to the last chunk of the file)
+		if lastChunkIndex == limit/ChunkSize {
+			lastChunkIndex--
+		}
 		lastOffsetInChunk = ChunkSize
 	}
 
 	ctx, cancel := context.WithCancelCause(ctx)
-	bufferSize := min(MaxBufferSize, lastChunkIndex-chunkIndex+1)
+	bufferSize := max(0, min(api.transferOptionsFor(ctx).DownloadReadAhead, lastChunkIndex-chunkIndex+1))
+	// the reader needs at least one chunk buffer, and reads ahead as far as the memory budget allows
+	memory := api.memory
+	if bufferSize > 0 {
+		if err := memory.reserve(ctx, 1); err != nil {
+			cancel(err)
+			bufferSize = 0
+		}
+		for reserved := 1; reserved < bufferSize; reserved++ {
+			if !memory.tryReserve(1) {
+				bufferSize = reserved
+				break
+			}
+		}
+	}
+	totalBytes := max(0, min(int64(file.Size), int64(lastChunkIndex+1)*ChunkSize)-int64(chunkIndex)*ChunkSize)
 
 	reader := &ChunkedReader{
 		file:              file,
@@ -151,11 +174,16 @@ func newChunkedReaderWithOffset(ctx context.Context, api *Filen, file *types.Fil
 		lastChunkIndex:    lastChunkIndex,
 		lastOffsetInChunk: lastOffsetInChunk,
 		totalRead:         totalRead,
+		memory:            memory,
+		progress:          newProgressTracker(ctx, file.UUID, file.Name, false, max(0, lastChunkIndex-chunkIndex+1), totalBytes),
 	}
 
 	// Init and prefetch initial chunks
+	// (all mutexes need to exist first, as the first chunk is not necessarily at buffer position 0)
 	for i := 0; i < bufferSize; i++ {
 		reader.buffer[i].ctxMu = NewCtxMutex()
+	}
+	for i := 0; i < bufferSize; i++ {
 		reader.goFetchChunk(i + chunkIndex)
 	}
 	return reader
@@ -177,6 +205,7 @@ func (r *ChunkedReader) fetchChunk(c *chunkState, chunkIndex int) {
 	}
 	copy(c.data[:], data)
 	c.size = len(data)
+	r.progress.chunkDone(chunkIndex, len(data))
 }
 
 func (r *ChunkedReader) goFetchChunk(chunkIndex int) {
@@ -256,6 +285,13 @@ func (r *ChunkedReader) Read(p []byte) (n int, err error) {
 // Close cleans up resources used by the reader
 func (r *ChunkedReader) Close() error {
 	r.cancel(fmt.Errorf("reader closed")) // Cancel all ongoing operations
+	r.releaseOnce.Do(func() {
+		// wait for running fetches to stop using their buffers
+		for i := range r.buffer {
+			_ = r.buffer[i].ctxMu.Lock(context.Background())
+		}
+		r.memory.release(len(r.buffer))
+	})
 
 	if r.totalRead != r.file.Size {
 		// incomplete read
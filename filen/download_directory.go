@@ -0,0 +1,194 @@
+package filen
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// SkipMode selects which files [Filen.DownloadDirectory] does not download again.
+type SkipMode int
+
+const (
+	// SkipNone downloads all files, replacing existing local files.
+	SkipNone SkipMode = iota
+	// SkipSameSize skips files whose local copy has the same size.
+	SkipSameSize
+	// SkipSameHash skips files whose local copy has the same size and SHA-512 hash.
+	// Files without a hash in their metadata are always downloaded.
+	SkipSameHash
+)
+
+// DownloadDirectoryOptions configures [Filen.DownloadDirectory].
+type DownloadDirectoryOptions struct {
+	// Include, if not empty, restricts the download to files matching at least one of the patterns,
+	// see [UploadDirectoryOptions.Include].
+	Include []string
+	// Exclude skips files and directories matching any of the patterns.
+	Exclude []string
+	// Parallelism is the number of files downloaded concurrently, defaults to [DefaultDirectoryParallelism].
+	Parallelism int
+	// Skip selects existing local files which are kept instead of downloading them again.
+	Skip SkipMode
+}
+
+// A DownloadResult is the outcome of downloading a single file of a directory.
+type DownloadResult struct {
+	Path    string      // the path relative to the downloaded directory, separated by slashes
+	File    *types.File // the remote file
+	Skipped bool        // whether the local file already matched, see [DownloadDirectoryOptions.Skip]
+	Err     error       // why the download failed
+}
+
+// checkLocalName returns an error if a remote name cannot be used as a local file name,
+// e.g. because it would escape the target directory.
+func checkLocalName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+	return nil
+}
+
+// localFileMatches reports whether the local file at localPath already has the content of file.
+func localFileMatches(localPath string, file *types.File, mode SkipMode) (bool, error) {
+	if mode == SkipNone || (mode == SkipSameHash && file.Hash == "") {
+		return false, nil
+	}
+	stat, err := os.Stat(localPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stat local file: %w", err)
+	}
+	if !stat.Mode().IsRegular() || stat.Size() != int64(file.Size) {
+		return false, nil
+	}
+	if mode == SkipSameSize {
+		return true, nil
+	}
+	f, err := os.Open(localPath)
+	if err != nil {
+		return false, fmt.Errorf("open local file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	hasher := sha512.New()
+	if _, err = io.Copy(hasher, f); err != nil {
+		return false, fmt.Errorf("hash local file: %w", err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)) == file.Hash, nil
+}
+
+// DownloadDirectory downloads the contents of dir into the local directory at localPath, which is created if needed.
+// The directory tree is listed with a single request, then files are downloaded concurrently.
+// Each file is written to a temporary file first and renamed when complete, and gets the remote modification time.
+//
+// A result is returned for every file, in the order of [DirectoryTree.Walk]. If any file could not be downloaded, the returned error
+// joins the failures. If the directory structure could not be listed or created, only the error is returned.
+func (api *Filen) DownloadDirectory(ctx context.Context, dir types.DirectoryInterface, localPath string, opts DownloadDirectoryOptions) ([]DownloadResult, error) {
+	if err := errors.Join(validatePatterns(opts.Include), validatePatterns(opts.Exclude)); err != nil {
+		return nil, err
+	}
+	tree, err := api.ReadDirectoryTree(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// create the directories and collect the files, leaving out excluded ones
+	results := make([]DownloadResult, 0)
+	err = tree.Walk(func(node *TreeNode) error {
+		if node != tree.Root && matchesAny(opts.Exclude, node.Path) {
+			if node.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !node.IsDir() && len(opts.Include) > 0 && !matchesAny(opts.Include, node.Path) {
+			return nil
+		}
+		// only names which are actually used locally need to be valid
+		if node != tree.Root {
+			if err := checkLocalName(node.Name()); err != nil {
+				if node.IsDir() {
+					return fmt.Errorf("directory %s: %w", node.UUID(), err)
+				}
+				results = append(results, DownloadResult{Path: node.Path, File: node.File, Err: err})
+				return nil
+			}
+		}
+		if node.IsDir() {
+			if err := os.MkdirAll(filepath.Join(localPath, filepath.FromSlash(node.Path)), 0o755); err != nil {
+				return fmt.Errorf("create directory: %w", err)
+			}
+			return nil
+		}
+		results = append(results, DownloadResult{Path: node.Path, File: node.File})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = DefaultDirectoryParallelism
+	}
+	sem := make(chan struct{}, parallelism)
+	wg := sync.WaitGroup{}
+	for i := range results {
+		result := &results[i]
+		if result.Err != nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			result.Err = context.Cause(ctx)
+			continue
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result.Skipped, result.Err = api.downloadDirectoryFile(ctx, result.File, filepath.Join(localPath, filepath.FromSlash(result.Path)), opts.Skip)
+		}()
+	}
+	wg.Wait()
+
+	errs := make([]error, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("download %s: %w", result.Path, result.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// downloadDirectoryFile downloads a file to localPath unless the local file matches, and sets its modification time.
+func (api *Filen) downloadDirectoryFile(ctx context.Context, file *types.File, localPath string, skip SkipMode) (bool, error) {
+	matches, err := localFileMatches(localPath, file, skip)
+	if err != nil {
+		return false, err
+	}
+	if matches {
+		return true, nil
+	}
+	if err = api.DownloadToPath(ctx, file, localPath); err != nil {
+		return false, err
+	}
+	if err = os.Chtimes(localPath, file.LastModified, file.LastModified); err != nil {
+		return false, fmt.Errorf("set modification time: %w", err)
+	}
+	return false, nil
+}
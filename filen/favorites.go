@@ -0,0 +1,49 @@
+package filen
+
+import (
+	"context"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/client"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+)
+
+// ListFavorites fetches the files and directories marked as favorites, wherever they are on the drive.
+// Items in the trash are not listed.
+func (api *Filen) ListFavorites(ctx context.Context) ([]*types.File, []*types.Directory, error) {
+	return api.ListFavoritesWithOptions(ctx, ReadDirectoryOptions{})
+}
+
+// ListFavoritesWithOptions is like [Filen.ListFavorites], configured by opts.
+func (api *Filen) ListFavoritesWithOptions(ctx context.Context, opts ReadDirectoryOptions) ([]*types.File, []*types.Directory, error) {
+	files, directories, err := api.readDirectory(ctx, client.FavoritesUUID, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list favorites: %w", err)
+	}
+	return files, directories, nil
+}
+
+// SetFavorite marks a file or directory as a favorite, or removes the mark.
+func (api *Filen) SetFavorite(ctx context.Context, item types.FileSystemObject, favorite bool) error {
+	itemType := "file"
+	if _, ok := item.(types.DirectoryInterface); ok {
+		itemType = "folder"
+	}
+	err := api.Client.PostV3ItemFavorite(ctx, item.GetUUID(), itemType, favorite)
+	if err != nil {
+		return fmt.Errorf("set favorite: %w", err)
+	}
+	if itemType == "folder" {
+		api.pathCache.update(item.GetUUID(), func(dir *types.Directory) { dir.Favorited = favorite })
+	}
+	return nil
+}
+
+// SetDirectoryColor changes the color of a directory.
+func (api *Filen) SetDirectoryColor(ctx context.Context, dir *types.Directory, color types.DirColor) error {
+	err := api.Client.PostV3DirColor(ctx, dir.UUID, color)
+	if err != nil {
+		return fmt.Errorf("set directory color: %w", err)
+	}
+	api.pathCache.update(dir.UUID, func(dir *types.Directory) { dir.Color = color })
+	return nil
+}
@@ -0,0 +1,326 @@
+package filen
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+	"hash"
+	"io"
+	"sync"
+)
+
+// A FileReader provides random access to the content of a file, see [Filen.OpenFile].
+//
+// Decrypted chunks are kept in a least-recently-used cache. While the file is read sequentially,
+// the following chunks are fetched ahead, up to [TransferOptions.DownloadReadAhead] chunks;
+// random access disables the read-ahead until reads are sequential again.
+//
+// When the whole file has been read in order (rereading earlier parts is fine), Close verifies the file hash.
+// The check is skipped if any part of the file was skipped over.
+type FileReader struct {
+	api      *Filen
+	file     *types.File
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	memory   *memoryBudget
+	progress *progressTracker
+
+	mu           sync.Mutex
+	capacity     int                  // the maximum number of cached chunks
+	chunks       map[int]*cachedChunk // cached chunks by index
+	lru          *list.List           // cached chunks, most recently used first
+	maxReadAhead int
+	readAhead    int   // the current number of chunks to fetch ahead
+	lastChunk    int   // the index of the chunk accessed last, or -1
+	offset       int64 // the position for Read and Seek
+	hasher       hash.Hash
+	hashed       int64 // the number of bytes from the start of the file included in hasher, or -1 if a part was skipped
+	closed       bool
+	fetched      *sync.Cond     // signalled on r.mu when a fetch finishes or the reader is closed
+	fetches      sync.WaitGroup // running fetches
+}
+
+// cachedChunk is a decrypted chunk, which may still be being fetched.
+type cachedChunk struct {
+	index   int
+	ready   chan struct{} // closed when data or err is set
+	data    []byte
+	err     error
+	element *list.Element
+}
+
+// OpenFile opens a file for random access. The reader must be closed to release its resources.
+func (api *Filen) OpenFile(ctx context.Context, file *types.File) (*FileReader, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	maxReadAhead := api.transferOptionsFor(ctx).DownloadReadAhead
+	// the cache holds the chunk being read and the read-ahead, as far as the memory budget allows
+	memory := api.memory
+	if err := memory.reserve(ctx, 1); err != nil {
+		cancel(err)
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	capacity := 1
+	for capacity < maxReadAhead+1 && memory.tryReserve(1) {
+		capacity++
+	}
+	r := &FileReader{
+		api:          api,
+		file:         file,
+		ctx:          ctx,
+		cancel:       cancel,
+		memory:       memory,
+		progress:     newProgressTracker(ctx, file.UUID, file.Name, false, -1, -1),
+		capacity:     capacity,
+		chunks:       make(map[int]*cachedChunk),
+		lru:          list.New(),
+		maxReadAhead: capacity - 1,
+		lastChunk:    -1,
+		hasher:       sha512.New(),
+	}
+	r.fetched = sync.NewCond(&r.mu)
+	return r, nil
+}
+
+// Size returns the size of the file.
+func (r *FileReader) Size() int64 {
+	return int64(r.file.Size)
+}
+
+// Read implements [io.Reader].
+func (r *FileReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	offset := r.offset
+	r.mu.Unlock()
+	n, err := r.readAt(p, offset, false)
+	r.mu.Lock()
+	r.offset = offset + int64(n)
+	r.mu.Unlock()
+	return n, err
+}
+
+// ReadAt implements [io.ReaderAt]. It may be called concurrently.
+func (r *FileReader) ReadAt(p []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	n, err := r.readAt(p, offset, true)
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Seek implements [io.Seeker].
+func (r *FileReader) Seek(offset int64, whence int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += int64(r.file.Size)
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// readAt reads from offset. If full is set, it reads until p is filled or the file ends,
+// otherwise it stops at the end of a chunk.
+func (r *FileReader) readAt(p []byte, offset int64, full bool) (int, error) {
+	if offset >= int64(r.file.Size) {
+		return 0, io.EOF
+	}
+	read := 0
+	for read < len(p) && offset < int64(r.file.Size) {
+		chunkIndex := int(offset / ChunkSize)
+		data, err := r.chunk(chunkIndex)
+		if err != nil {
+			return read, err
+		}
+		n := copy(p[read:], data[offset%ChunkSize:])
+		r.hash(p[read:read+n], offset)
+		read += n
+		offset += int64(n)
+		if !full {
+			break
+		}
+	}
+	return read, nil
+}
+
+// hash adds data read at offset to the file hash, if it continues the data hashed so far.
+func (r *FileReader) hash(data []byte, offset int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hashed < 0 || offset > r.hashed {
+		r.hashed = -1
+		return
+	}
+	end := offset + int64(len(data))
+	if end > r.hashed {
+		r.hasher.Write(data[r.hashed-offset:])
+		r.hashed = end
+	}
+}
+
+// chunk returns the decrypted chunk, fetching it and the read-ahead if necessary.
+func (r *FileReader) chunk(chunkIndex int) ([]byte, error) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, errors.New("reader closed")
+	}
+	if chunkIndex == r.lastChunk || chunkIndex == r.lastChunk+1 {
+		r.readAhead = min(r.maxReadAhead, max(1, r.readAhead*2))
+	} else {
+		r.readAhead = 0
+	}
+	r.lastChunk = chunkIndex
+	c := r.getLocked(chunkIndex, nil)
+	if c == nil {
+		r.mu.Unlock()
+		if err := context.Cause(r.ctx); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("reader closed")
+	}
+	// the read-ahead only uses free cache slots and never waits for one
+	for i := chunkIndex + 1; i <= chunkIndex+r.readAhead && i < r.file.Chunks; i++ {
+		if _, ok := r.chunks[i]; !ok && r.getLocked(i, c) == nil {
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-c.ready:
+	case <-r.ctx.Done():
+		return nil, context.Cause(r.ctx)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.data, nil
+}
+
+// getLocked returns the cached chunk, or starts fetching it. r.mu must be held.
+//
+// Chunks which are still being fetched are never evicted, so at most r.capacity chunks are in memory.
+// If the cache is full, getLocked evicts the least recently used completed chunk other than keep.
+// If there is none, it waits for a fetch to finish, unless keep is set (for the read-ahead), in which case it returns nil.
+// It also returns nil if the reader is closed.
+func (r *FileReader) getLocked(chunkIndex int, keep *cachedChunk) *cachedChunk {
+	for {
+		if r.closed || r.ctx.Err() != nil {
+			return nil
+		}
+		if c, ok := r.chunks[chunkIndex]; ok {
+			r.lru.MoveToFront(c.element)
+			return c
+		}
+		if r.lru.Len() < r.capacity || r.evictCompletedLocked(keep) {
+			break
+		}
+		if keep != nil {
+			return nil
+		}
+		r.fetched.Wait()
+	}
+	c := &cachedChunk{index: chunkIndex, ready: make(chan struct{})}
+	c.element = r.lru.PushFront(c)
+	r.chunks[chunkIndex] = c
+	r.fetches.Add(1)
+	go r.fetch(c)
+	return c
+}
+
+// evictCompletedLocked evicts the least recently used chunk which has been fetched, other than keep.
+// It reports whether a chunk was evicted. r.mu must be held.
+func (r *FileReader) evictCompletedLocked(keep *cachedChunk) bool {
+	for e := r.lru.Back(); e != nil; e = e.Prev() {
+		c := e.Value.(*cachedChunk)
+		if c == keep {
+			continue
+		}
+		select {
+		case <-c.ready:
+			r.evictLocked(c)
+			return true
+		default:
+		}
+	}
+	return false
+}
+
+// evictLocked removes a chunk from the cache. r.mu must be held.
+func (r *FileReader) evictLocked(c *cachedChunk) {
+	if r.chunks[c.index] == c {
+		delete(r.chunks, c.index)
+		r.lru.Remove(c.element)
+	}
+}
+
+func (r *FileReader) fetch(c *cachedChunk) {
+	defer r.fetches.Done()
+	data, err := r.api.fetchAndDecryptChunk(r.ctx, r.file, c.index)
+	if err == nil {
+		expected := min(ChunkSize, r.file.Size-c.index*ChunkSize)
+		if len(data) != expected {
+			err = fmt.Errorf("chunk %d has %d bytes, expected %d", c.index, len(data), expected)
+		}
+	}
+	if err == nil {
+		r.progress.chunkDone(c.index, len(data))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		c.err = err
+		// a later access should retry
+		r.evictLocked(c)
+	} else {
+		c.data = data
+	}
+	close(c.ready)
+	r.fetched.Broadcast()
+}
+
+// Close releases the reader's resources. If the whole file has been read, it verifies the file hash.
+func (r *FileReader) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.cancel(errors.New("reader closed"))
+	r.fetched.Broadcast()
+	r.mu.Unlock()
+
+	// wait for running fetches to stop using their buffers
+	r.fetches.Wait()
+	r.memory.release(r.capacity)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chunks = nil
+	r.lru.Init()
+
+	if r.hashed != int64(r.file.Size) || r.file.Hash == "" {
+		return nil
+	}
+	if h := hex.EncodeToString(r.hasher.Sum(nil)); h != r.file.Hash {
+		return fmt.Errorf("hash mismatch: expected %s, got %s", r.file.Hash, h)
+	}
+	return nil
+}
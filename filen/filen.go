@@ -4,6 +4,7 @@ package filen
 import (
 	"context"
 	"crypto/rsa"
+	"errors"
 	"fmt"
 	"github.com/FilenCloudDienste/filen-sdk-go/filen/client"
 	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
@@ -31,13 +32,30 @@ type Filen struct {
 
 	// BaseFolderUUID is the UUID of the cloud drive's root directory
 	BaseFolder types.RootDirectory
+
+	transferOptions TransferOptions
+	memory          *memoryBudget
+	pathCache       *pathCache // nil if disabled
 }
 
 // New creates a new Filen and initializes it with the given email and password
 // by logging in with the API and preparing the API key and master keys.
 func New(ctx context.Context, email, password string) (*Filen, error) {
-	unauthorizedClient := client.New(ctx)
+	return NewWithClient(ctx, email, password, client.New(ctx))
+}
 
+// NewWithClient is like [New], but sends all requests through the given client.
+func NewWithClient(ctx context.Context, email, password string, unauthorizedClient *client.UnauthorizedClient) (*Filen, error) {
+	return newWithClient(ctx, email, password, unauthorizedClient, TwoFactor{})
+}
+
+// NewWithTwoFactor is like [New], for accounts with two-factor authentication enabled.
+// The twoFactorCode is the current two-factor code or the account's two-factor recovery key.
+func NewWithTwoFactor(ctx context.Context, email, password, twoFactorCode string) (*Filen, error) {
+	return newWithClient(ctx, email, password, client.New(ctx), TwoFactor{Code: twoFactorCode})
+}
+
+func newWithClient(ctx context.Context, email, password string, unauthorizedClient *client.UnauthorizedClient, twoFactor TwoFactor) (*Filen, error) {
 	// fetch salt
 	authInfo, err := unauthorizedClient.PostV3AuthInfo(ctx, email)
 	if err != nil {
@@ -46,20 +64,23 @@ func New(ctx context.Context, email, password string) (*Filen, error) {
 
 	switch authInfo.AuthVersion {
 	case 1:
-		panic("unimplemented")
+		return newV1(ctx, email, password, *authInfo, unauthorizedClient, twoFactor)
 	case 2:
-		return newV2(ctx, email, password, *authInfo, unauthorizedClient)
+		return newV2(ctx, email, password, *authInfo, unauthorizedClient, twoFactor)
 	case 3:
-		return newV3(ctx, email, password, *authInfo, unauthorizedClient)
+		return newV3(ctx, email, password, *authInfo, unauthorizedClient, twoFactor)
 	default:
-		panic("unimplemented")
+		return nil, fmt.Errorf("unsupported auth version %d", authInfo.AuthVersion)
 	}
 }
 
 // NewWithAPIKey creates a new Filen and initializes it with the given email, password, and API key
 func NewWithAPIKey(ctx context.Context, email, password, apiKey string) (*Filen, error) {
-	c := client.NewWithAPIKey(ctx, apiKey)
+	return NewWithAuthorizedClient(ctx, email, password, client.NewWithAPIKey(ctx, apiKey))
+}
 
+// NewWithAuthorizedClient is like [NewWithAPIKey], but uses the given client and its API key.
+func NewWithAuthorizedClient(ctx context.Context, email, password string, c *client.Client) (*Filen, error) {
 	authInfo, err := c.PostV3AuthInfo(ctx, email)
 	if err != nil {
 		return nil, err
@@ -67,13 +88,13 @@ func NewWithAPIKey(ctx context.Context, email, password, apiKey string) (*Filen,
 
 	switch authInfo.AuthVersion {
 	case 1:
-		panic("unimplemented")
+		return newV1WithAPIKey(ctx, email, password, *authInfo, c)
 	case 2:
 		return newV2WithAPIKey(ctx, email, password, *authInfo, c)
 	case 3:
 		return newV3WithAPIKey(ctx, email, password, *authInfo, c)
 	default:
-		panic("unimplemented")
+		return nil, fmt.Errorf("unsupported auth version %d", authInfo.AuthVersion)
 	}
 }
 
@@ -100,7 +121,7 @@ func getMasterKeys(ctx context.Context, masterKey crypto.MasterKey, c *client.Cl
 	if err != nil {
 		return nil, fmt.Errorf("failed to get master keys: %w", err)
 	}
-	masterKeysStr, err := masterKey.DecryptMetaV2(mkResponse.Keys)
+	masterKeysStr, err := masterKey.DecryptMeta(mkResponse.Keys)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt master keys meta: %w", err)
 	}
@@ -129,13 +150,48 @@ func getDEK(ctx context.Context, kek crypto.EncryptionKey, c *client.Client) (*c
 	return dek, nil
 }
 
-func loginV2(ctx context.Context, email, password string, info client.V3AuthInfoResponse, uc *client.UnauthorizedClient) (*client.Client, *crypto.MasterKey, error) {
+// login calls the login endpoint, asking for two-factor codes as configured by twoFactor.
+func login(ctx context.Context, email string, derivedPass crypto.DerivedPassword, authVersion int, uc *client.UnauthorizedClient, twoFactor TwoFactor) (*client.V3LoginResponse, error) {
+	code := twoFactor.Code
+	if code == "" {
+		code = twoFactor.RecoveryKey
+	}
+	for prompts := 0; ; prompts++ {
+		response, err := uc.PostV3Login(ctx, email, derivedPass, authVersion, code)
+		if err == nil {
+			return response, nil
+		}
+		if twoFactor.Prompt == nil || prompts >= maxTwoFactorPrompts ||
+			!(errors.Is(err, client.ErrTwoFactorRequired) || errors.Is(err, client.ErrWrongTwoFactorCode)) {
+			return nil, err
+		}
+		code, err = twoFactor.Prompt(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("two-factor prompt: %w", err)
+		}
+	}
+}
+
+func loginV1(ctx context.Context, email, password string, info client.V3AuthInfoResponse, uc *client.UnauthorizedClient, twoFactor TwoFactor) (*client.Client, *crypto.MasterKey, error) {
+	masterKey, derivedPass, err := crypto.DeriveMKAndAuthFromPasswordV1(password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("DeriveMKAndAuthFromPasswordV1: %w", err)
+	}
+	response, err := login(ctx, email, derivedPass, info.AuthVersion, uc, twoFactor)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to log in: %w", err)
+	}
+	c := uc.Authorize(response.APIKey)
+	return c, masterKey, nil
+}
+
+func loginV2(ctx context.Context, email, password string, info client.V3AuthInfoResponse, uc *client.UnauthorizedClient, twoFactor TwoFactor) (*client.Client, *crypto.MasterKey, error) {
 	masterKey, derivedPass, err := crypto.DeriveMKAndAuthFromPassword(password, info.Salt)
 	if err != nil {
 		return nil, nil, fmt.Errorf("DeriveMKAndAuthFromPassword: %w", err)
 	}
 	// for simplicity, I'm going to ignore the fact that response here contains the RSAKeypair
-	response, err := uc.PostV3Login(ctx, email, derivedPass, info.AuthVersion)
+	response, err := login(ctx, email, derivedPass, info.AuthVersion, uc, twoFactor)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to log in: %w", err)
 	}
@@ -143,13 +199,13 @@ func loginV2(ctx context.Context, email, password string, info client.V3AuthInfo
 	return c, masterKey, nil
 }
 
-func loginV3(ctx context.Context, email, password string, info client.V3AuthInfoResponse, uc *client.UnauthorizedClient) (*client.Client, *crypto.EncryptionKey, error) {
+func loginV3(ctx context.Context, email, password string, info client.V3AuthInfoResponse, uc *client.UnauthorizedClient, twoFactor TwoFactor) (*client.Client, *crypto.EncryptionKey, error) {
 	kek, derivedPass, err := crypto.DeriveKEKAndAuthFromPassword(password, info.Salt)
 	if err != nil {
 		return nil, nil, fmt.Errorf("DeriveKEKAndAuthFromPassword: %w", err)
 	}
 	// for simplicity, I'm going to ignore the fact that response here contains the RSAKeypair
-	response, err := uc.PostV3Login(ctx, email, derivedPass, info.AuthVersion)
+	response, err := login(ctx, email, derivedPass, info.AuthVersion, uc, twoFactor)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to log in: %w", err)
 	}
@@ -158,6 +214,26 @@ func loginV3(ctx context.Context, email, password string, info client.V3AuthInfo
 	return c, kek, nil
 }
 
+// v1 accounts use master keys like v2 accounts, so everything after deriving the master key is shared with v2.
+
+func newV1(ctx context.Context, email, password string, info client.V3AuthInfoResponse, uc *client.UnauthorizedClient, twoFactor TwoFactor) (*Filen, error) {
+	c, masterKey, err := loginV1(ctx, email, password, info, uc, twoFactor)
+	if err != nil {
+		return nil, fmt.Errorf("loginV1: %w", err)
+	}
+
+	return newV2Authed(ctx, email, info, c, *masterKey)
+}
+
+func newV1WithAPIKey(ctx context.Context, email, password string, info client.V3AuthInfoResponse, c *client.Client) (*Filen, error) {
+	masterKey, _, err := crypto.DeriveMKAndAuthFromPasswordV1(password)
+	if err != nil {
+		return nil, fmt.Errorf("DeriveMKAndAuthFromPasswordV1: %w", err)
+	}
+
+	return newV2Authed(ctx, email, info, c, *masterKey)
+}
+
 func newV2Authed(ctx context.Context, email string, info client.V3AuthInfoResponse, c *client.Client, masterKey crypto.MasterKey) (*Filen, error) {
 	masterKeys, err := getMasterKeys(ctx, masterKey, c)
 	if err != nil {
@@ -185,8 +261,8 @@ func newV2Authed(ctx context.Context, email string, info client.V3AuthInfoRespon
 	}, nil
 }
 
-func newV2(ctx context.Context, email, password string, info client.V3AuthInfoResponse, uc *client.UnauthorizedClient) (*Filen, error) {
-	c, masterKey, err := loginV2(ctx, email, password, info, uc)
+func newV2(ctx context.Context, email, password string, info client.V3AuthInfoResponse, uc *client.UnauthorizedClient, twoFactor TwoFactor) (*Filen, error) {
+	c, masterKey, err := loginV2(ctx, email, password, info, uc, twoFactor)
 	if err != nil {
 		return nil, fmt.Errorf("loginV2: %w", err)
 	}
@@ -232,8 +308,8 @@ func newV3Authed(ctx context.Context, email string, info client.V3AuthInfoRespon
 	}, nil
 }
 
-func newV3(ctx context.Context, email, password string, info client.V3AuthInfoResponse, uc *client.UnauthorizedClient) (*Filen, error) {
-	c, kek, err := loginV3(ctx, email, password, info, uc)
+func newV3(ctx context.Context, email, password string, info client.V3AuthInfoResponse, uc *client.UnauthorizedClient, twoFactor TwoFactor) (*Filen, error) {
+	c, kek, err := loginV3(ctx, email, password, info, uc, twoFactor)
 	if err != nil {
 		return nil, fmt.Errorf("loginV3: %w", err)
 	}
@@ -0,0 +1,233 @@
+package filentest
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+// account is a registered user. Key material is stored encrypted, like on the real API.
+type account struct {
+	email       string
+	salt        string
+	authVersion int
+	password    crypto.DerivedPassword // the derived password sent on login
+
+	masterKeys crypto.EncryptedString // v1 and v2: the master keys, encrypted with the current master key
+	dek        crypto.EncryptedString // v3: the DEK, encrypted with the KEK
+	publicKey  string
+	privateKey crypto.EncryptedString // encrypted with the master key (v1 and v2) or DEK (v3)
+	baseFolder string                 // UUID of the root directory
+
+	twoFactorCode        string // the accepted two-factor code, or empty if two-factor authentication is disabled
+	twoFactorRecoveryKey string
+}
+
+// AddAccount registers an account which can then log in with email and password.
+// Supported auth versions are 1, 2 and 3. The private key of v1 accounts is stored in the deprecated
+// OpenSSL-compatible metadata format, like for legacy accounts on the real API.
+func (s *Server) AddAccount(email, password string, authVersion int) error {
+	salt := crypto.GenerateRandomString(64)
+	acc := &account{
+		email:       email,
+		salt:        salt,
+		authVersion: authVersion,
+		baseFolder:  uuid.NewString(),
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return fmt.Errorf("generate rsa key: %w", err)
+	}
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		return fmt.Errorf("marshal private key: %w", err)
+	}
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		return fmt.Errorf("marshal public key: %w", err)
+	}
+	privateKeyStr := base64.StdEncoding.EncodeToString(privateKeyBytes)
+	acc.publicKey = base64.StdEncoding.EncodeToString(publicKeyBytes)
+
+	switch authVersion {
+	case 1:
+		masterKey, derivedPass, err := crypto.DeriveMKAndAuthFromPasswordV1(password)
+		if err != nil {
+			return fmt.Errorf("derive master key: %w", err)
+		}
+		acc.password = derivedPass
+		acc.masterKeys = masterKey.EncryptMeta(masterKey.Key())
+		acc.privateKey, err = encryptMetaV1([]byte(masterKey.Key()), privateKeyStr)
+		if err != nil {
+			return fmt.Errorf("encrypt private key: %w", err)
+		}
+	case 2:
+		masterKey, derivedPass, err := crypto.DeriveMKAndAuthFromPassword(password, salt)
+		if err != nil {
+			return fmt.Errorf("derive master key: %w", err)
+		}
+		acc.password = derivedPass
+		acc.masterKeys = masterKey.EncryptMeta(masterKey.Key())
+		acc.privateKey = masterKey.EncryptMeta(privateKeyStr)
+	case 3:
+		kek, derivedPass, err := crypto.DeriveKEKAndAuthFromPassword(password, salt)
+		if err != nil {
+			return fmt.Errorf("derive kek: %w", err)
+		}
+		dek, err := crypto.NewEncryptionKey()
+		if err != nil {
+			return fmt.Errorf("generate dek: %w", err)
+		}
+		acc.password = derivedPass
+		acc.dek = kek.EncryptMeta(dek.ToString())
+		acc.privateKey = dek.EncryptMeta(privateKeyStr)
+	default:
+		return fmt.Errorf("unsupported auth version %d", authVersion)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.accounts[email]; ok {
+		return fmt.Errorf("account %s already exists", email)
+	}
+	s.accounts[email] = acc
+	s.items[acc.baseFolder] = &item{
+		uuid:      acc.baseFolder,
+		owner:     acc,
+		directory: true,
+		timestamp: time.Now().Unix(),
+	}
+	return nil
+}
+
+// EnableTwoFactor enables two-factor authentication for an account.
+// Logging in then requires the given code (the server does not implement TOTP) or the recovery key.
+func (s *Server) EnableTwoFactor(email, code, recoveryKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc, ok := s.accounts[email]
+	if !ok {
+		return fmt.Errorf("account %s does not exist", email)
+	}
+	acc.twoFactorCode = code
+	acc.twoFactorRecoveryKey = recoveryKey
+	return nil
+}
+
+// encryptMetaV1 encrypts metadata like CryptoJS.AES.encrypt with a passphrase, which is how v1 clients did it.
+// The SDK only decrypts this format.
+func encryptMetaV1(passphrase []byte, metadata string) (crypto.EncryptedString, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	// EVP_BytesToKey with MD5 and a single iteration
+	var derived, block []byte
+	for len(derived) < 48 {
+		hash := md5.Sum(append(append(block, passphrase...), salt...))
+		block = hash[:]
+		derived = append(derived, block...)
+	}
+	c, err := aes.NewCipher(derived[:32])
+	if err != nil {
+		return "", err
+	}
+	padding := aes.BlockSize - len(metadata)%aes.BlockSize
+	plaintext := append([]byte(metadata), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	encrypted := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(c, derived[32:48]).CryptBlocks(encrypted, plaintext)
+	return crypto.EncryptedString(base64.StdEncoding.EncodeToString(append(append([]byte("Salted__"), salt...), encrypted...))), nil
+}
+
+func (s *Server) handleAuthInfo(r *http.Request) (any, *apiError) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	acc, ok := s.accounts[req.Email]
+	if !ok {
+		// the real API does not reveal whether an account exists
+		return map[string]any{"authVersion": 2, "salt": crypto.GenerateRandomString(64)}, nil
+	}
+	return map[string]any{"authVersion": acc.authVersion, "salt": acc.salt}, nil
+}
+
+func (s *Server) handleLogin(r *http.Request) (any, *apiError) {
+	var req struct {
+		Email         string `json:"email"`
+		Password      string `json:"password"`
+		TwoFactorCode string `json:"twoFactorCode"`
+		AuthVersion   int    `json:"authVersion"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	acc, ok := s.accounts[req.Email]
+	if !ok || string(acc.password) != req.Password || acc.authVersion != req.AuthVersion {
+		return nil, &apiError{http.StatusUnauthorized, "email_or_password_wrong", "Invalid email or password."}
+	}
+	if acc.twoFactorCode != "" {
+		switch {
+		case req.TwoFactorCode == "" || req.TwoFactorCode == "XXXXXX":
+			return nil, &apiError{http.StatusForbidden, "enter_2fa", "Please enter your two-factor code."}
+		case req.TwoFactorCode != acc.twoFactorCode && req.TwoFactorCode != acc.twoFactorRecoveryKey:
+			return nil, &apiError{http.StatusForbidden, "wrong_2fa", "Invalid two-factor code."}
+		}
+	}
+
+	apiKey := crypto.GenerateRandomString(64)
+	s.sessions[apiKey] = acc
+	return map[string]any{
+		"apiKey":     apiKey,
+		"masterKeys": acc.masterKeys,
+		"publicKey":  acc.publicKey,
+		"privateKey": acc.privateKey,
+		"dek":        acc.dek,
+	}, nil
+}
+
+func (s *Server) handleUserMasterKeys(acc *account, r *http.Request) (any, *apiError) {
+	var req struct {
+		MasterKeys crypto.EncryptedString `json:"masterKeys"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	return map[string]any{"keys": acc.masterKeys}, nil
+}
+
+func (s *Server) handleGetUserDEK(acc *account, _ *http.Request) (any, *apiError) {
+	return map[string]any{"dek": acc.dek}, nil
+}
+
+func (s *Server) handlePostUserDEK(acc *account, r *http.Request) (any, *apiError) {
+	var req struct {
+		DEK crypto.EncryptedString `json:"dek"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	acc.dek = req.DEK
+	return nil, nil
+}
+
+func (s *Server) handleUserKeyPairInfo(acc *account, _ *http.Request) (any, *apiError) {
+	return map[string]any{"publicKey": acc.publicKey, "privateKey": acc.privateKey}, nil
+}
+
+func (s *Server) handleUserBaseFolder(acc *account, _ *http.Request) (any, *apiError) {
+	return map[string]any{"uuid": acc.baseFolder}, nil
+}
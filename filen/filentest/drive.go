@@ -0,0 +1,607 @@
+package filentest
+
+import (
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
+	"net/http"
+	"time"
+)
+
+// item is a file or directory on the drive.
+type item struct {
+	uuid       string
+	parent     string // UUID of the parent directory, or empty for a root directory
+	owner      *account
+	directory  bool
+	metadata   crypto.EncryptedString // the file metadata, or the directory's encrypted metadata
+	name       crypto.EncryptedString // files only: the name encrypted with the file key
+	nameHashed string
+	mime       crypto.EncryptedString
+	timestamp  int64 // unix seconds
+	favorited  bool
+	color      string
+	trashed    bool
+
+	// files only
+	size       int
+	chunks     int
+	region     string
+	bucket     string
+	rm         string
+	version    int
+	replacedBy string // UUID of the newer version which replaced this file, if any
+}
+
+// lookup returns the item with the given UUID if it belongs to acc and is visible on the drive.
+func (s *Server) lookup(acc *account, uuid string, directory bool) *item {
+	it, ok := s.items[uuid]
+	if !ok || it.owner != acc || it.directory != directory || !s.visible(it) {
+		return nil
+	}
+	return it
+}
+
+// visible reports whether an item is neither replaced nor in the trash, directly or through an ancestor.
+func (s *Server) visible(it *item) bool {
+	for it != nil {
+		if it.trashed || it.replacedBy != "" {
+			return false
+		}
+		it = s.items[it.parent]
+	}
+	return true
+}
+
+// SetMetadata replaces the encrypted metadata of a file or directory,
+// e.g. to simulate items which cannot be decrypted.
+func (s *Server) SetMetadata(uuid string, metadata crypto.EncryptedString) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[uuid]
+	if !ok {
+		return fmt.Errorf("item %s does not exist", uuid)
+	}
+	it.metadata = metadata
+	return nil
+}
+
+func fileNotFound() *apiError {
+	return notFound("file_not_found", "File not found.")
+}
+
+func folderNotFound() *apiError {
+	return notFound("folder_not_found", "Folder not found.")
+}
+
+// children returns the items directly inside the directory with the given UUID which are
+// neither replaced nor in the trash.
+func (s *Server) children(parent string) []*item {
+	children := make([]*item, 0)
+	for _, it := range s.items {
+		if it.parent == parent && !it.trashed && it.replacedBy == "" {
+			children = append(children, it)
+		}
+	}
+	return children
+}
+
+// trashed returns the items of acc which were trashed themselves (not through an ancestor).
+func (s *Server) trashed(acc *account) []*item {
+	items := make([]*item, 0)
+	for _, it := range s.items {
+		if it.owner == acc && it.trashed && it.replacedBy == "" {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+// favorites returns the visible items of acc which are marked as favorites.
+func (s *Server) favorites(acc *account) []*item {
+	items := make([]*item, 0)
+	for _, it := range s.items {
+		if it.owner == acc && it.favorited && s.visible(it) {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+// findByName returns the visible child of parent with the given hashed name.
+func (s *Server) findByName(parent string, nameHashed string, directory bool) *item {
+	for _, it := range s.children(parent) {
+		if it.directory == directory && it.nameHashed == nameHashed {
+			return it
+		}
+	}
+	return nil
+}
+
+// deleteItem removes an item and, for directories, all of its descendants.
+func (s *Server) deleteItem(it *item) {
+	if it.directory {
+		for _, child := range s.itemsWithParent(it.uuid) {
+			s.deleteItem(child)
+		}
+	}
+	delete(s.items, it.uuid)
+	delete(s.chunks, it.uuid)
+}
+
+// itemsWithParent returns all items directly inside the directory, including trashed ones.
+func (s *Server) itemsWithParent(parent string) []*item {
+	items := make([]*item, 0)
+	for _, it := range s.items {
+		if it.parent == parent {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func fileResponse(it *item) map[string]any {
+	return map[string]any{
+		"uuid":      it.uuid,
+		"metadata":  it.metadata,
+		"rm":        it.rm,
+		"timestamp": it.timestamp,
+		"chunks":    it.chunks,
+		"size":      it.size,
+		"bucket":    it.bucket,
+		"region":    it.region,
+		"parent":    it.parent,
+		"version":   it.version,
+		"favorited": boolToInt(it.favorited),
+	}
+}
+
+func folderResponse(it *item) map[string]any {
+	return map[string]any{
+		"uuid":       it.uuid,
+		"name":       it.metadata,
+		"parent":     it.parent,
+		"color":      it.color,
+		"timestamp":  it.timestamp,
+		"favorited":  boolToInt(it.favorited),
+		"is_sync":    0,
+		"is_default": 0,
+	}
+}
+
+type uuidRequest struct {
+	UUID string `json:"uuid"`
+}
+
+// lookupAny returns the item with the given UUID if it belongs to acc and is not replaced, even if it is in the trash.
+func (s *Server) lookupAny(acc *account, uuid string, directory bool) *item {
+	it, ok := s.items[uuid]
+	if !ok || it.owner != acc || it.directory != directory || it.replacedBy != "" {
+		return nil
+	}
+	return it
+}
+
+func (s *Server) handleFile(acc *account, r *http.Request) (any, *apiError) {
+	var req uuidRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	file := s.lookupAny(acc, req.UUID, false)
+	if file == nil {
+		return nil, fileNotFound()
+	}
+	return map[string]any{
+		"uuid":          file.uuid,
+		"region":        file.region,
+		"bucket":        file.bucket,
+		"nameEncrypted": file.name,
+		"nameHashed":    file.nameHashed,
+		"sizeEncrypted": "",
+		"mimeEncrypted": file.mime,
+		"metadata":      file.metadata,
+		"size":          file.size,
+		"parent":        file.parent,
+		"versioned":     false,
+		"trash":         file.trashed,
+		"version":       file.version,
+	}, nil
+}
+
+func (s *Server) handleDir(acc *account, r *http.Request) (any, *apiError) {
+	var req uuidRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	dir := s.lookupAny(acc, req.UUID, true)
+	if dir == nil {
+		return nil, folderNotFound()
+	}
+	return map[string]any{
+		"uuid":          dir.uuid,
+		"nameEncrypted": dir.metadata,
+		"nameHashed":    dir.nameHashed,
+		"parent":        dir.parent,
+		"trash":         dir.trashed,
+		"favorited":     dir.favorited,
+		"color":         dir.color,
+	}, nil
+}
+
+func (s *Server) handleDirContent(acc *account, r *http.Request) (any, *apiError) {
+	var req uuidRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	var items []*item
+	if req.UUID == "trash" {
+		items = s.trashed(acc)
+	} else if req.UUID == "favorites" {
+		items = s.favorites(acc)
+	} else if s.lookup(acc, req.UUID, true) == nil {
+		return nil, folderNotFound()
+	} else {
+		items = s.children(req.UUID)
+	}
+	uploads := make([]map[string]any, 0)
+	folders := make([]map[string]any, 0)
+	for _, it := range items {
+		if it.directory {
+			folders = append(folders, folderResponse(it))
+		} else {
+			uploads = append(uploads, fileResponse(it))
+		}
+	}
+	return map[string]any{"uploads": uploads, "folders": folders}, nil
+}
+
+func (s *Server) handleDirDownload(acc *account, r *http.Request) (any, *apiError) {
+	var req struct {
+		UUID string `json:"uuid"`
+		Type string `json:"type"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.Type != "normal" {
+		return nil, badRequest("Invalid type.")
+	}
+	dir := s.lookup(acc, req.UUID, true)
+	if dir == nil {
+		return nil, folderNotFound()
+	}
+	// like the real API, the directory itself is included
+	files := make([]map[string]any, 0)
+	folders := []map[string]any{folderResponse(dir)}
+	for queue := []*item{dir}; len(queue) > 0; queue = queue[1:] {
+		for _, it := range s.children(queue[0].uuid) {
+			if it.directory {
+				folders = append(folders, folderResponse(it))
+				queue = append(queue, it)
+			} else {
+				files = append(files, fileResponse(it))
+			}
+		}
+	}
+	return map[string]any{"files": files, "folders": folders}, nil
+}
+
+func (s *Server) handleDirSize(acc *account, r *http.Request) (any, *apiError) {
+	var req uuidRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	dir := s.lookup(acc, req.UUID, true)
+	if dir == nil {
+		return nil, folderNotFound()
+	}
+	size, files, folders := 0, 0, 0
+	for queue := []*item{dir}; len(queue) > 0; queue = queue[1:] {
+		for _, it := range s.children(queue[0].uuid) {
+			if it.directory {
+				folders++
+				queue = append(queue, it)
+			} else {
+				files++
+				size += it.size
+			}
+		}
+	}
+	return map[string]any{"size": size, "files": files, "folders": folders}, nil
+}
+
+func (s *Server) handleDirCreate(acc *account, r *http.Request) (any, *apiError) {
+	var req struct {
+		UUID       string                 `json:"uuid"`
+		Name       crypto.EncryptedString `json:"name"`
+		NameHashed string                 `json:"nameHashed"`
+		Parent     string                 `json:"parent"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if s.lookup(acc, req.Parent, true) == nil {
+		return nil, folderNotFound()
+	}
+	// like the real API, creating an existing directory returns the existing one
+	if existing := s.findByName(req.Parent, req.NameHashed, true); existing != nil {
+		return map[string]any{"uuid": existing.uuid}, nil
+	}
+	if _, ok := s.items[req.UUID]; ok {
+		return nil, badRequest("UUID already in use.")
+	}
+	s.items[req.UUID] = &item{
+		uuid:       req.UUID,
+		parent:     req.Parent,
+		owner:      acc,
+		directory:  true,
+		metadata:   req.Name,
+		nameHashed: req.NameHashed,
+		timestamp:  time.Now().Unix(),
+	}
+	return map[string]any{"uuid": req.UUID}, nil
+}
+
+func (s *Server) handleDirTrash(acc *account, r *http.Request) (any, *apiError) {
+	var req uuidRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	dir := s.lookup(acc, req.UUID, true)
+	if dir == nil || dir.uuid == acc.baseFolder {
+		return nil, folderNotFound()
+	}
+	dir.trashed = true
+	return nil, nil
+}
+
+func (s *Server) handleDirDeletePermanent(acc *account, r *http.Request) (any, *apiError) {
+	var req uuidRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	dir, ok := s.items[req.UUID]
+	if !ok || dir.owner != acc || !dir.directory || dir.uuid == acc.baseFolder {
+		return nil, folderNotFound()
+	}
+	s.deleteItem(dir)
+	return nil, nil
+}
+
+func (s *Server) handleFileTrash(acc *account, r *http.Request) (any, *apiError) {
+	var req uuidRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	file := s.lookup(acc, req.UUID, false)
+	if file == nil {
+		return nil, fileNotFound()
+	}
+	file.trashed = true
+	return nil, nil
+}
+
+func (s *Server) handleFileDeletePermanent(acc *account, r *http.Request) (any, *apiError) {
+	var req uuidRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	file, ok := s.items[req.UUID]
+	if !ok || file.owner != acc || file.directory {
+		return nil, fileNotFound()
+	}
+	s.deleteItem(file)
+	return nil, nil
+}
+
+func (s *Server) handleFileMetadata(acc *account, r *http.Request) (any, *apiError) {
+	var req struct {
+		UUID       string                 `json:"uuid"`
+		Name       crypto.EncryptedString `json:"name"`
+		NameHashed string                 `json:"nameHashed"`
+		Metadata   crypto.EncryptedString `json:"metadata"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	file := s.lookup(acc, req.UUID, false)
+	if file == nil {
+		return nil, fileNotFound()
+	}
+	file.name = req.Name
+	file.nameHashed = req.NameHashed
+	file.metadata = req.Metadata
+	return nil, nil
+}
+
+func (s *Server) handleItemFavorite(acc *account, r *http.Request) (any, *apiError) {
+	var req struct {
+		UUID  string `json:"uuid"`
+		Type  string `json:"type"`
+		Value int    `json:"value"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	var it *item
+	switch req.Type {
+	case "file":
+		if it = s.lookup(acc, req.UUID, false); it == nil {
+			return nil, fileNotFound()
+		}
+	case "folder":
+		if it = s.lookup(acc, req.UUID, true); it == nil || it.uuid == acc.baseFolder {
+			return nil, folderNotFound()
+		}
+	default:
+		return nil, badRequest("Invalid type.")
+	}
+	it.favorited = req.Value == 1
+	return nil, nil
+}
+
+func (s *Server) handleDirColor(acc *account, r *http.Request) (any, *apiError) {
+	var req struct {
+		UUID  string `json:"uuid"`
+		Color string `json:"color"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	dir := s.lookup(acc, req.UUID, true)
+	if dir == nil || dir.uuid == acc.baseFolder {
+		return nil, folderNotFound()
+	}
+	if req.Color == "" {
+		return nil, badRequest("Invalid color.")
+	}
+	// listings report the default color as an empty color
+	dir.color = req.Color
+	if req.Color == "default" {
+		dir.color = ""
+	}
+	return nil, nil
+}
+
+type existsRequest struct {
+	Parent     string `json:"parent"`
+	NameHashed string `json:"nameHashed"`
+}
+
+// handleExists reports whether the parent directory contains a file or directory with the hashed name.
+func (s *Server) handleExists(acc *account, r *http.Request, directory bool) (any, *apiError) {
+	var req existsRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if s.lookup(acc, req.Parent, true) == nil {
+		return nil, folderNotFound()
+	}
+	existing := s.findByName(req.Parent, req.NameHashed, directory)
+	if existing == nil {
+		return map[string]any{"exists": false, "uuid": ""}, nil
+	}
+	return map[string]any{"exists": true, "uuid": existing.uuid}, nil
+}
+
+func (s *Server) handleFileExists(acc *account, r *http.Request) (any, *apiError) {
+	return s.handleExists(acc, r, false)
+}
+
+func (s *Server) handleDirExists(acc *account, r *http.Request) (any, *apiError) {
+	return s.handleExists(acc, r, true)
+}
+
+type moveRequest struct {
+	UUID string `json:"uuid"`
+	To   string `json:"to"`
+}
+
+func (s *Server) handleFileMove(acc *account, r *http.Request) (any, *apiError) {
+	var req moveRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	file := s.lookup(acc, req.UUID, false)
+	if file == nil {
+		return nil, fileNotFound()
+	}
+	if s.lookup(acc, req.To, true) == nil {
+		return nil, folderNotFound()
+	}
+	file.parent = req.To
+	return nil, nil
+}
+
+func (s *Server) handleDirMove(acc *account, r *http.Request) (any, *apiError) {
+	var req moveRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	dir := s.lookup(acc, req.UUID, true)
+	if dir == nil || dir.uuid == acc.baseFolder {
+		return nil, folderNotFound()
+	}
+	target := s.lookup(acc, req.To, true)
+	if target == nil {
+		return nil, folderNotFound()
+	}
+	for ancestor := target; ancestor != nil; ancestor = s.items[ancestor.parent] {
+		if ancestor == dir {
+			return nil, badRequest("Cannot move a directory into itself.")
+		}
+	}
+	dir.parent = req.To
+	return nil, nil
+}
+
+func (s *Server) handleFileRename(acc *account, r *http.Request) (any, *apiError) {
+	// the request has the same fields as /v3/file/metadata
+	return s.handleFileMetadata(acc, r)
+}
+
+func (s *Server) handleDirRename(acc *account, r *http.Request) (any, *apiError) {
+	var req struct {
+		UUID       string                 `json:"uuid"`
+		Name       crypto.EncryptedString `json:"name"`
+		NameHashed string                 `json:"nameHashed"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	dir := s.lookup(acc, req.UUID, true)
+	if dir == nil || dir.uuid == acc.baseFolder {
+		return nil, folderNotFound()
+	}
+	dir.metadata = req.Name
+	dir.nameHashed = req.NameHashed
+	return nil, nil
+}
+
+// lookupTrashed returns the item with the given UUID if it belongs to acc and is in the trash.
+func (s *Server) lookupTrashed(acc *account, uuid string, directory bool) *item {
+	it, ok := s.items[uuid]
+	if !ok || it.owner != acc || it.directory != directory || !it.trashed {
+		return nil
+	}
+	return it
+}
+
+func (s *Server) handleFileRestore(acc *account, r *http.Request) (any, *apiError) {
+	var req uuidRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	file := s.lookupTrashed(acc, req.UUID, false)
+	if file == nil {
+		return nil, fileNotFound()
+	}
+	file.trashed = false
+	return nil, nil
+}
+
+func (s *Server) handleDirRestore(acc *account, r *http.Request) (any, *apiError) {
+	var req uuidRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	dir := s.lookupTrashed(acc, req.UUID, true)
+	if dir == nil {
+		return nil, folderNotFound()
+	}
+	dir.trashed = false
+	return nil, nil
+}
+
+func (s *Server) handleTrashEmpty(acc *account, _ *http.Request) (any, *apiError) {
+	for _, it := range s.trashed(acc) {
+		s.deleteItem(it)
+	}
+	return nil, nil
+}
@@ -0,0 +1,267 @@
+// Package filentest provides an in-memory stand-in for the Filen API, for use in tests.
+//
+// A [Server] implements the gateway, ingest and egest endpoints used by the client package
+// on a single local host. It stores items exactly as the SDK sends them (i.e. encrypted),
+// so it exercises the same code paths as the real API.
+package filentest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/client"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Server is an in-memory Filen API. Create it with [NewServer] and close it when done.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	accounts map[string]*account       // accounts by email
+	sessions map[string]*account       // logged-in accounts by API key
+	items    map[string]*item          // files and directories by UUID
+	uploads  map[string]*upload        // in-progress uploads by file UUID
+	chunks   map[string]map[int][]byte // encrypted chunks of completed files by file UUID
+
+	failures      int    // number of upcoming requests to fail
+	failureStatus int    // HTTP status code for failed requests
+	failAfter     bool   // whether failed requests are applied before failing
+	failPath      string // if set, only requests to this path fail
+}
+
+// NewServer starts a new Server with no accounts.
+func NewServer() *Server {
+	s := &Server{
+		accounts: make(map[string]*account),
+		sessions: make(map[string]*account),
+		items:    make(map[string]*item),
+		uploads:  make(map[string]*upload),
+		chunks:   make(map[string]map[int][]byte),
+	}
+	s.Server = httptest.NewServer(s.injectFailures(s.routes()))
+	return s
+}
+
+// FailRequests makes the next n requests fail with the given HTTP status code, e.g. to test retries.
+func (s *Server) FailRequests(n int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+	s.failureStatus = status
+	s.failAfter = false
+	s.failPath = ""
+}
+
+// FailRequestsAfterApplying makes the next n requests fail with the given HTTP status code
+// after they have been applied, like a connection which breaks before the response arrives.
+func (s *Server) FailRequestsAfterApplying(n int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+	s.failureStatus = status
+	s.failAfter = true
+	s.failPath = ""
+}
+
+// FailRequestsTo is like [Server.FailRequests], or [Server.FailRequestsAfterApplying] if afterApplying is set,
+// but only fails requests to the given path, e.g. "/v3/upload/done".
+func (s *Server) FailRequestsTo(path string, n int, status int, afterApplying bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+	s.failureStatus = status
+	s.failAfter = afterApplying
+	s.failPath = path
+}
+
+func (s *Server) injectFailures(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		fail := s.failures > 0 && (s.failPath == "" || s.failPath == r.URL.Path)
+		if fail {
+			s.failures--
+		}
+		status := s.failureStatus
+		failAfter := s.failAfter
+		s.mu.Unlock()
+		if fail && failAfter {
+			next.ServeHTTP(httptest.NewRecorder(), r)
+		}
+		if fail {
+			writeError(w, &apiError{status, "internal_error", "Injected failure."})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientOptions returns client options which send all requests to the server.
+func (s *Server) ClientOptions() client.Options {
+	return client.Options{
+		Endpoints: client.SingleHostEndpoints(s.URL),
+		Transport: s.Client().Transport,
+	}
+}
+
+// NewClient returns a client which sends all requests to the server.
+func (s *Server) NewClient(ctx context.Context) *client.UnauthorizedClient {
+	return client.NewWithOptions(ctx, s.ClientOptions())
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	// auth
+	mux.HandleFunc("POST /v3/auth/info", s.public(s.handleAuthInfo))
+	mux.HandleFunc("POST /v3/login", s.public(s.handleLogin))
+
+	// user
+	mux.HandleFunc("POST /v3/user/masterKeys", s.authed(s.handleUserMasterKeys))
+	mux.HandleFunc("GET /v3/user/dek", s.authed(s.handleGetUserDEK))
+	mux.HandleFunc("POST /v3/user/dek", s.authed(s.handlePostUserDEK))
+	mux.HandleFunc("GET /v3/user/keyPair/info", s.authed(s.handleUserKeyPairInfo))
+	mux.HandleFunc("GET /v3/user/baseFolder", s.authed(s.handleUserBaseFolder))
+
+	// directories
+	mux.HandleFunc("POST /v3/dir", s.authed(s.handleDir))
+	mux.HandleFunc("POST /v3/dir/color", s.authed(s.handleDirColor))
+	mux.HandleFunc("POST /v3/dir/content", s.authed(s.handleDirContent))
+	mux.HandleFunc("POST /v3/dir/create", s.authed(s.handleDirCreate))
+	mux.HandleFunc("POST /v3/dir/trash", s.authed(s.handleDirTrash))
+	mux.HandleFunc("POST /v3/dir/delete/permanent", s.authed(s.handleDirDeletePermanent))
+	mux.HandleFunc("POST /v3/dir/move", s.authed(s.handleDirMove))
+	mux.HandleFunc("POST /v3/dir/rename", s.authed(s.handleDirRename))
+	mux.HandleFunc("POST /v3/dir/restore", s.authed(s.handleDirRestore))
+	mux.HandleFunc("POST /v3/dir/download", s.authed(s.handleDirDownload))
+	mux.HandleFunc("POST /v3/dir/exists", s.authed(s.handleDirExists))
+	mux.HandleFunc("POST /v3/dir/size", s.authed(s.handleDirSize))
+
+	// files
+	mux.HandleFunc("POST /v3/file", s.authed(s.handleFile))
+	mux.HandleFunc("POST /v3/file/trash", s.authed(s.handleFileTrash))
+	mux.HandleFunc("POST /v3/file/delete/permanent", s.authed(s.handleFileDeletePermanent))
+	mux.HandleFunc("POST /v3/file/exists", s.authed(s.handleFileExists))
+	mux.HandleFunc("POST /v3/file/metadata", s.authed(s.handleFileMetadata))
+	mux.HandleFunc("POST /v3/file/move", s.authed(s.handleFileMove))
+	mux.HandleFunc("POST /v3/file/rename", s.authed(s.handleFileRename))
+	mux.HandleFunc("POST /v3/file/restore", s.authed(s.handleFileRestore))
+	mux.HandleFunc("POST /v3/file/versions", s.authed(s.handleFileVersions))
+	mux.HandleFunc("POST /v3/file/version/restore", s.authed(s.handleFileVersionRestore))
+
+	// trash
+	mux.HandleFunc("POST /v3/trash/empty", s.authed(s.handleTrashEmpty))
+
+	// favorites
+	mux.HandleFunc("POST /v3/item/favorite", s.authed(s.handleItemFavorite))
+
+	// uploads
+	mux.HandleFunc("POST /v3/upload", s.authedRaw(s.handleUploadChunk))
+	mux.HandleFunc("POST /v3/upload/empty", s.authed(s.handleUploadEmpty))
+	mux.HandleFunc("POST /v3/upload/done", s.authed(s.handleUploadDone))
+
+	// downloads
+	mux.HandleFunc("GET /{region}/{bucket}/{uuid}/{chunk}", s.authedRaw(s.handleDownloadChunk))
+
+	return mux
+}
+
+// apiError is an error reported to the client in the response envelope.
+type apiError struct {
+	status  int    // the HTTP status code
+	code    string // the Filen error code
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.code, e.message)
+}
+
+func badRequest(message string) *apiError {
+	return &apiError{http.StatusBadRequest, "bad_request", message}
+}
+
+func notFound(code string, message string) *apiError {
+	return &apiError{http.StatusNotFound, code, message}
+}
+
+// envelope is the JSON body of every gateway response.
+type envelope struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Code    string `json:"code"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, body envelope) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, err *apiError) {
+	writeJSON(w, err.status, envelope{Status: false, Message: err.message, Code: err.code})
+}
+
+// decodeBody unmarshals a JSON request body into v.
+func decodeBody(r *http.Request, v any) *apiError {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return badRequest(fmt.Sprintf("cannot read body: %v", err))
+	}
+	if err = json.Unmarshal(body, v); err != nil {
+		return badRequest(fmt.Sprintf("cannot unmarshal body: %v", err))
+	}
+	return nil
+}
+
+type publicHandler func(r *http.Request) (any, *apiError)
+type authedHandler func(acc *account, r *http.Request) (any, *apiError)
+type rawHandler func(acc *account, w http.ResponseWriter, r *http.Request) *apiError
+
+// public wraps a handler that does not require an API key.
+func (s *Server) public(h publicHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		data, err := h(r)
+		s.mu.Unlock()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, envelope{Status: true, Message: "OK", Data: data})
+	}
+}
+
+// authed wraps a handler that requires a valid API key and responds with a JSON envelope.
+func (s *Server) authed(h authedHandler) http.HandlerFunc {
+	return s.authedRaw(func(acc *account, w http.ResponseWriter, r *http.Request) *apiError {
+		data, err := h(acc, r)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, envelope{Status: true, Message: "OK", Data: data})
+		return nil
+	})
+}
+
+// authedRaw wraps a handler that requires a valid API key and writes its own successful response.
+func (s *Server) authedRaw(h rawHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		apiKey, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		acc := s.sessions[apiKey]
+		if !ok || acc == nil {
+			writeError(w, &apiError{http.StatusUnauthorized, "api_key_not_found", "Invalid API key."})
+			return
+		}
+		if err := h(acc, w, r); err != nil {
+			writeError(w, err)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package filentest
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	region = "de-1"
+	bucket = "filen-1"
+)
+
+// upload is a file whose chunks are being uploaded but which has not been completed yet.
+type upload struct {
+	owner     *account
+	parent    string
+	uploadKey string
+	chunks    map[int][]byte
+}
+
+func (s *Server) handleUploadChunk(acc *account, w http.ResponseWriter, r *http.Request) *apiError {
+	query := r.URL.Query()
+	fileUUID := query.Get("uuid")
+	parent := query.Get("parent")
+	uploadKey := query.Get("uploadKey")
+	index, err := strconv.Atoi(query.Get("index"))
+	if err != nil || index < 0 || fileUUID == "" || uploadKey == "" {
+		return badRequest("Invalid upload parameters.")
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return badRequest(fmt.Sprintf("cannot read body: %v", err))
+	}
+	hash := sha512.Sum512(data)
+	if hex.EncodeToString(hash[:]) != query.Get("hash") {
+		return badRequest("Chunk hash mismatch.")
+	}
+	if s.lookup(acc, parent, true) == nil {
+		return folderNotFound()
+	}
+
+	u, ok := s.uploads[fileUUID]
+	if !ok {
+		u = &upload{owner: acc, parent: parent, uploadKey: uploadKey, chunks: make(map[int][]byte)}
+		s.uploads[fileUUID] = u
+	} else if u.owner != acc || u.uploadKey != uploadKey || u.parent != parent {
+		return badRequest("Upload parameters do not match the upload in progress.")
+	}
+	u.chunks[index] = data
+
+	writeJSON(w, http.StatusOK, envelope{
+		Status:  true,
+		Message: "OK",
+		Data:    map[string]any{"bucket": bucket, "region": region},
+	})
+	return nil
+}
+
+type uploadEmptyRequest struct {
+	UUID       string                 `json:"uuid"`
+	Name       crypto.EncryptedString `json:"name"`
+	NameHashed string                 `json:"nameHashed"`
+	Size       string                 `json:"size"`
+	Parent     string                 `json:"parent"`
+	MimeType   crypto.EncryptedString `json:"mime"`
+	Metadata   crypto.EncryptedString `json:"metadata"`
+	Version    int                    `json:"version"`
+}
+
+// addFile adds a completed file to the drive. An existing file with the same name is replaced.
+func (s *Server) addFile(acc *account, req uploadEmptyRequest, size int, chunks int, rm string) *apiError {
+	if s.lookup(acc, req.Parent, true) == nil {
+		return folderNotFound()
+	}
+	if _, ok := s.items[req.UUID]; ok {
+		return badRequest("UUID already in use.")
+	}
+	if existing := s.findByName(req.Parent, req.NameHashed, false); existing != nil {
+		existing.replacedBy = req.UUID
+	}
+	file := &item{
+		uuid:       req.UUID,
+		parent:     req.Parent,
+		owner:      acc,
+		metadata:   req.Metadata,
+		name:       req.Name,
+		nameHashed: req.NameHashed,
+		mime:       req.MimeType,
+		timestamp:  time.Now().Unix(),
+		size:       size,
+		chunks:     chunks,
+		rm:         rm,
+		version:    req.Version,
+	}
+	if chunks > 0 {
+		file.region = region
+		file.bucket = bucket
+	}
+	s.items[file.uuid] = file
+	return nil
+}
+
+func (s *Server) handleUploadEmpty(acc *account, r *http.Request) (any, *apiError) {
+	var req uploadEmptyRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if err := s.addFile(acc, req, 0, 0, ""); err != nil {
+		return nil, err
+	}
+	return map[string]any{"chunks": 0, "size": 0}, nil
+}
+
+func (s *Server) handleUploadDone(acc *account, r *http.Request) (any, *apiError) {
+	var req struct {
+		uploadEmptyRequest
+		Chunks    int    `json:"chunks"`
+		Rm        string `json:"rm"`
+		UploadKey string `json:"uploadKey"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	u, ok := s.uploads[req.UUID]
+	if !ok || u.owner != acc || u.uploadKey != req.UploadKey {
+		return nil, notFound("upload_not_found", "Upload not found.")
+	}
+	for i := 0; i < req.Chunks; i++ {
+		if _, ok := u.chunks[i]; !ok {
+			return nil, badRequest(fmt.Sprintf("Chunk %d missing.", i))
+		}
+	}
+	size, err := strconv.Atoi(req.Size)
+	if err != nil {
+		return nil, badRequest("Invalid size.")
+	}
+	if apiErr := s.addFile(acc, req.uploadEmptyRequest, size, req.Chunks, req.Rm); apiErr != nil {
+		return nil, apiErr
+	}
+	delete(s.uploads, req.UUID)
+	s.chunks[req.UUID] = u.chunks
+	return map[string]any{"chunks": req.Chunks, "size": size}, nil
+}
+
+func (s *Server) handleDownloadChunk(acc *account, w http.ResponseWriter, r *http.Request) *apiError {
+	file, ok := s.items[r.PathValue("uuid")]
+	if !ok || file.owner != acc || file.directory ||
+		file.region != r.PathValue("region") || file.bucket != r.PathValue("bucket") {
+		return fileNotFound()
+	}
+	index, err := strconv.Atoi(r.PathValue("chunk"))
+	if err != nil {
+		return badRequest("Invalid chunk index.")
+	}
+	data, ok := s.chunks[file.uuid][index]
+	if !ok {
+		return notFound("chunk_not_found", "Chunk not found.")
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+	return nil
+}
+
+// versions returns the current file and its previous versions, newest first.
+func (s *Server) versions(current *item) []*item {
+	versions := []*item{current}
+	for i := 0; i < len(versions); i++ {
+		previous := make([]*item, 0)
+		for _, it := range s.items {
+			if it.replacedBy == versions[i].uuid {
+				previous = append(previous, it)
+			}
+		}
+		sort.Slice(previous, func(a, b int) bool { return previous[a].timestamp > previous[b].timestamp })
+		versions = append(versions, previous...)
+	}
+	return versions
+}
+
+func (s *Server) handleFileVersions(acc *account, r *http.Request) (any, *apiError) {
+	var req uuidRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	file := s.lookup(acc, req.UUID, false)
+	if file == nil {
+		return nil, fileNotFound()
+	}
+	versions := make([]map[string]any, 0)
+	for _, it := range s.versions(file) {
+		versions = append(versions, map[string]any{
+			"uuid":      it.uuid,
+			"bucket":    it.bucket,
+			"region":    it.region,
+			"chunks":    it.chunks,
+			"metadata":  it.metadata,
+			"rm":        it.rm,
+			"timestamp": it.timestamp,
+			"version":   it.version,
+		})
+	}
+	return map[string]any{"versions": versions}, nil
+}
+
+func (s *Server) handleFileVersionRestore(acc *account, r *http.Request) (any, *apiError) {
+	var req struct {
+		UUID    string `json:"uuid"`
+		Current string `json:"current"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	current := s.lookup(acc, req.Current, false)
+	if current == nil {
+		return nil, fileNotFound()
+	}
+	for _, version := range s.versions(current)[1:] {
+		if version.uuid == req.UUID {
+			version.replacedBy = ""
+			version.parent = current.parent
+			current.replacedBy = version.uuid
+			return nil, nil
+		}
+	}
+	return nil, fileNotFound()
+}
@@ -0,0 +1,92 @@
+package filen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+	"slices"
+	"strings"
+)
+
+// GetFile fetches a file by its UUID. Files in the trash are found as well.
+//
+// The API does not report whether the file is a favorite, so Favorited is always false;
+// use [Filen.ReadDirectory] or [Filen.ListFavorites] where it matters.
+func (api *Filen) GetFile(ctx context.Context, uuid string) (*types.File, error) {
+	response, err := api.Client.PostV3File(ctx, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("get file: %w", err)
+	}
+	// the response includes neither the number of chunks nor the favorite flag
+	file, err := api.newFile(response.UUID, response.Parent, response.Metadata, response.Region, response.Bucket, 0, false)
+	if err != nil {
+		return nil, &ItemDecryptionError{UUID: response.UUID, Err: err}
+	}
+	file.Chunks = (file.Size + ChunkSize - 1) / ChunkSize
+	return file, nil
+}
+
+// GetDirectory fetches a directory by its UUID. Directories in the trash are found as well.
+// The base folder cannot be fetched, use [Filen.BaseFolder] instead.
+//
+// The API does not report the server-side timestamp of the directory, so Created is
+// only set if the creation time is stored in the directory's metadata, and is zero otherwise.
+func (api *Filen) GetDirectory(ctx context.Context, uuid string) (*types.Directory, error) {
+	directory, _, err := api.getDirectory(ctx, uuid)
+	return directory, err
+}
+
+// getDirectory fetches a directory by its UUID and reports whether it is in the trash.
+func (api *Filen) getDirectory(ctx context.Context, uuid string) (*types.Directory, bool, error) {
+	if uuid == api.BaseFolder.UUID {
+		return nil, false, errors.New("get directory: cannot get the base folder")
+	}
+	response, err := api.Client.PostV3Dir(ctx, uuid)
+	if err != nil {
+		return nil, false, fmt.Errorf("get directory: %w", err)
+	}
+	// the response has no timestamp to fall back on
+	directory, err := api.newDirectory(response.UUID, response.Parent, response.Metadata, response.Color, 0, response.Favorited)
+	if err != nil {
+		return nil, false, &ItemDecryptionError{UUID: response.UUID, Directory: true, Err: err}
+	}
+	return directory, response.Trash, nil
+}
+
+// GetPath returns the path of a file or directory relative to the base folder, in the format accepted by [Filen.FindItem].
+// It fetches the item's ancestors one by one, except for those found in the path cache (see [Filen.EnablePathCache]).
+// For an item in the trash, it returns the path the item was trashed from.
+func (api *Filen) GetPath(ctx context.Context, item types.FileSystemObject) (string, error) {
+	if item.GetUUID() == api.BaseFolder.UUID {
+		return "", nil
+	}
+	// walk up to the base folder or a cached directory, collecting the names from the item upwards
+	names := []string{item.GetName()}
+	ancestors := make([]*types.Directory, 0)
+	inTrash := false
+	prefix, parent := "", item.GetParent()
+	for parent != api.BaseFolder.UUID {
+		if cachedPath, ok := api.pathCache.pathOf(parent); ok {
+			prefix = cachedPath
+			break
+		}
+		directory, trashed, err := api.getDirectory(ctx, parent)
+		if err != nil {
+			return "", err
+		}
+		names = append(names, directory.Name)
+		ancestors = append(ancestors, directory)
+		inTrash = inTrash || trashed
+		parent = directory.ParentUUID
+	}
+	slices.Reverse(names)
+
+	if !inTrash {
+		// ancestors[i] is at names[len(names)-2-i]
+		for i, directory := range ancestors {
+			api.pathCache.put(normalizePath(prefix+"/"+strings.Join(names[:len(names)-1-i], "/")), directory)
+		}
+	}
+	return normalizePath(prefix + "/" + strings.Join(names, "/")), nil
+}
@@ -0,0 +1,50 @@
+package filen
+
+import (
+	"context"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/client"
+)
+
+// Options configures a Filen created with [NewWithOptions].
+type Options struct {
+	// Client configures how requests are sent, e.g. to self-hosted proxies or through a custom transport.
+	Client client.Options
+	// APIKey, if set, is used instead of logging in, like in [NewWithAPIKey].
+	APIKey string
+	// TwoFactor is used to log in to accounts with two-factor authentication enabled.
+	TwoFactor TwoFactor
+	// Transfers configures the concurrency and memory use of file transfers, see [Filen.SetTransferOptions].
+	Transfers TransferOptions
+}
+
+// maxTwoFactorPrompts is how often [TwoFactor.Prompt] is called before giving up.
+const maxTwoFactorPrompts = 3
+
+// TwoFactor provides two-factor authentication when logging in.
+type TwoFactor struct {
+	// Code is the current two-factor (TOTP) code.
+	Code string
+	// RecoveryKey is the account's two-factor recovery key, which is used if Code is empty.
+	RecoveryKey string
+	// Prompt, if set, is called to obtain a code when the API reports that one is required
+	// or that the given one was wrong. It is called up to 3 times per login.
+	Prompt func(ctx context.Context) (string, error)
+}
+
+// NewWithOptions creates a new Filen like [New] or, if opts.APIKey is set, like [NewWithAPIKey],
+// with a client configured by opts.
+func NewWithOptions(ctx context.Context, email, password string, opts Options) (*Filen, error) {
+	unauthorizedClient := client.NewWithOptions(ctx, opts.Client)
+	var api *Filen
+	var err error
+	if opts.APIKey != "" {
+		api, err = NewWithAuthorizedClient(ctx, email, password, unauthorizedClient.Authorize(opts.APIKey))
+	} else {
+		api, err = newWithClient(ctx, email, password, unauthorizedClient, opts.TwoFactor)
+	}
+	if err != nil {
+		return nil, err
+	}
+	api.SetTransferOptions(opts.Transfers)
+	return api, nil
+}
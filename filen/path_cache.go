@@ -0,0 +1,182 @@
+package filen
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+)
+
+// pathCache maps the paths of directories to the directories, so that path lookups don't need to list
+// every ancestor directory. Files are not cached, they are always looked up in their parent directory.
+type pathCache struct {
+	mu      sync.Mutex
+	root    string // the UUID of the base folder, whose path is ""
+	ttl     time.Duration
+	entries map[string]pathCacheEntry // by normalized path
+	paths   map[string]string         // the cached path of each directory UUID
+	now     func() time.Time
+}
+
+type pathCacheEntry struct {
+	directory *types.Directory
+	expires   time.Time // zero if the entry does not expire
+}
+
+// normalizePath returns the path segments joined by slashes, without empty segments.
+func normalizePath(path string) string {
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// EnablePathCache makes [Filen.FindItem], [Filen.FindFile], [Filen.FindDirectory] and [Filen.FindDirectoryOrCreate]
+// remember the directories they resolve, for at most ttl (or until invalidated if ttl is 0).
+// The cache is updated when this Filen creates, trashes, moves or renames directories, but changes made
+// elsewhere are only noticed after the entries expired or were removed with [Filen.InvalidatePath].
+// It must not be called concurrently with other methods.
+func (api *Filen) EnablePathCache(ttl time.Duration) {
+	api.pathCache = &pathCache{
+		root:    api.BaseFolder.UUID,
+		ttl:     ttl,
+		entries: make(map[string]pathCacheEntry),
+		paths:   make(map[string]string),
+		now:     time.Now,
+	}
+}
+
+// DisablePathCache turns off and clears the path cache. It must not be called concurrently with other methods.
+func (api *Filen) DisablePathCache() {
+	api.pathCache = nil
+}
+
+// InvalidatePath removes the cached directory at path and everything below it from the path cache.
+// An empty path clears the whole cache.
+func (api *Filen) InvalidatePath(path string) {
+	api.pathCache.removePath(normalizePath(path))
+}
+
+// get returns the cached directory at the normalized path. A nil *pathCache caches nothing.
+func (c *pathCache) get(path string) *types.Directory {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[path]
+	if !ok {
+		return nil
+	}
+	if !entry.expires.IsZero() && c.now().After(entry.expires) {
+		delete(c.entries, path)
+		delete(c.paths, entry.directory.UUID)
+		return nil
+	}
+	return entry.directory
+}
+
+// put caches the directory at the normalized path.
+func (c *pathCache) put(path string, directory *types.Directory) {
+	if c == nil || path == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := pathCacheEntry{directory: directory}
+	if c.ttl > 0 {
+		entry.expires = c.now().Add(c.ttl)
+	}
+	if old, ok := c.entries[path]; ok {
+		delete(c.paths, old.directory.UUID)
+	}
+	c.entries[path] = entry
+	c.paths[directory.UUID] = path
+}
+
+// pathOf returns the normalized path of the directory with the given UUID, if it is the base folder or cached.
+func (c *pathCache) pathOf(uuid string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	if uuid == c.root {
+		return "", true
+	}
+	c.mu.Lock()
+	path, ok := c.paths[uuid]
+	c.mu.Unlock()
+	if !ok || c.get(path) == nil {
+		return "", false
+	}
+	return path, true
+}
+
+// putChild caches a directory created or moved into its parent directory, if the path of the parent is known.
+func (c *pathCache) putChild(directory *types.Directory) {
+	if parentPath, ok := c.pathOf(directory.ParentUUID); ok {
+		c.put(normalizePath(parentPath+"/"+directory.Name), directory)
+	}
+}
+
+// update replaces the cached directory with the given UUID by a copy modified by fn.
+func (c *pathCache) update(uuid string, fn func(directory *types.Directory)) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	path, ok := c.paths[uuid]
+	if !ok {
+		return
+	}
+	entry := c.entries[path]
+	updated := *entry.directory
+	fn(&updated)
+	entry.directory = &updated
+	c.entries[path] = entry
+}
+
+// removePath removes the entry at the normalized path and all entries below it.
+func (c *pathCache) removePath(path string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for p, entry := range c.entries {
+		if path == "" || p == path || strings.HasPrefix(p, path+"/") {
+			delete(c.entries, p)
+			delete(c.paths, entry.directory.UUID)
+		}
+	}
+}
+
+// removeDirectory removes the directory with the given UUID and everything below it.
+func (c *pathCache) removeDirectory(uuid string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	path, ok := c.paths[uuid]
+	c.mu.Unlock()
+	if ok {
+		c.removePath(path)
+	}
+}
+
+// deepestCached returns the deepest cached directory among the ancestors of the path segments (including the
+// full path), and the number of segments it covers. It returns the base folder and 0 if none is cached.
+func (api *Filen) deepestCached(segments []string) (types.DirectoryInterface, int) {
+	if api.pathCache != nil {
+		for i := len(segments); i > 0; i-- {
+			if dir := api.pathCache.get(strings.Join(segments[:i], "/")); dir != nil {
+				return dir, i
+			}
+		}
+	}
+	return &api.BaseFolder, 0
+}
@@ -0,0 +1,106 @@
+package filen
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Progress describes the progress of a file transfer. It is reported to a [ProgressFunc]
+// each time a chunk has been transferred.
+type Progress struct {
+	UUID   string // the UUID of the file
+	Name   string // the name of the file
+	Upload bool   // whether the file is uploaded, otherwise it is downloaded
+
+	ChunkIndex  int     // the index of the chunk which has just been transferred
+	Chunks      int     // the number of chunks transferred so far
+	TotalChunks int     // the number of chunks to transfer, or -1 if unknown
+	Bytes       int64   // the number of (unencrypted) bytes transferred so far
+	TotalBytes  int64   // the number of bytes to transfer, or -1 if unknown
+	Throughput  float64 // the current transfer rate in bytes per second
+}
+
+// A ProgressFunc receives progress updates, see [WithProgress].
+// It is not called concurrently for the same transfer, but should return quickly, as it blocks the transfer.
+type ProgressFunc func(progress Progress)
+
+type progressKey struct{}
+
+// WithProgress returns a context which reports the progress of file transfers started with it to fn.
+// This works for all uploads and downloads, e.g. [Filen.UploadFile], [Filen.GetDownloadReader] and
+// [Filen.DownloadToPath]. To receive updates on a channel, send to it from fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// throughputWindow is the number of recent chunks the throughput is calculated from.
+const throughputWindow = 8
+
+type progressSample struct {
+	time  time.Time
+	bytes int64
+}
+
+// progressTracker collects the progress of a single transfer. A nil *progressTracker discards all updates.
+type progressTracker struct {
+	mu       sync.Mutex
+	fn       ProgressFunc
+	progress Progress
+	samples  []progressSample // the most recent samples, oldest first
+}
+
+// newProgressTracker returns a tracker for a transfer, or nil if ctx has no [ProgressFunc].
+func newProgressTracker(ctx context.Context, uuid, name string, upload bool, totalChunks int, totalBytes int64) *progressTracker {
+	fn, ok := ctx.Value(progressKey{}).(ProgressFunc)
+	if !ok || fn == nil {
+		return nil
+	}
+	return &progressTracker{
+		fn: fn,
+		progress: Progress{
+			UUID:        uuid,
+			Name:        name,
+			Upload:      upload,
+			TotalChunks: totalChunks,
+			TotalBytes:  totalBytes,
+		},
+		samples: []progressSample{{time: time.Now()}},
+	}
+}
+
+// skip records chunks which had already been transferred before the transfer started, e.g. when resuming an upload.
+func (t *progressTracker) skip(chunks int, bytes int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress.Chunks += chunks
+	t.progress.Bytes += bytes
+	t.samples[0].bytes = t.progress.Bytes
+}
+
+// chunkDone records a transferred chunk and reports the progress.
+func (t *progressTracker) chunkDone(chunkIndex int, size int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress.ChunkIndex = chunkIndex
+	t.progress.Chunks++
+	t.progress.Bytes += int64(size)
+
+	now := progressSample{time: time.Now(), bytes: t.progress.Bytes}
+	oldest := t.samples[0]
+	if elapsed := now.time.Sub(oldest.time).Seconds(); elapsed > 0 {
+		t.progress.Throughput = float64(now.bytes-oldest.bytes) / elapsed
+	}
+	t.samples = append(t.samples, now)
+	if len(t.samples) > throughputWindow {
+		t.samples = t.samples[1:]
+	}
+
+	t.fn(t.progress)
+}
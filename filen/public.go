@@ -2,12 +2,11 @@ package filen
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
 	"io"
 	"os"
-	"path"
+	"path/filepath"
 )
 
 // DownloadToPath downloads a file from the cloud to the given downloadPath.
@@ -15,7 +14,7 @@ import (
 // then renamed to the final path. If an error occurs during download or rename,
 // the temporary file is removed.
 func (api *Filen) DownloadToPath(ctx context.Context, file *types.File, downloadPath string) error {
-	downloadDir := path.Dir(downloadPath)
+	downloadDir := filepath.Dir(downloadPath)
 	// needs to be removed or renamed
 	f, err := os.CreateTemp(downloadDir, fmt.Sprintf("%s-download-*.tmp", file.Name))
 	if err != nil {
@@ -26,6 +25,7 @@ func (api *Filen) DownloadToPath(ctx context.Context, file *types.File, download
 	_, err = f.ReadFrom(downloader)
 	errClose := f.Close()
 	if err != nil {
+		_ = downloader.Close()
 		_ = os.Remove(fName)
 		maybeErr := context.Cause(ctx)
 		if maybeErr != nil {
@@ -66,20 +66,10 @@ func (api *Filen) UploadFromReader(ctx context.Context, file *types.IncompleteFi
 }
 
 func (api *Filen) UpdateMeta(ctx context.Context, file *types.File) error {
-	metaData := FileMetadata{
-		Name:         file.Name,
-		Size:         file.Size,
-		MimeType:     file.MimeType,
-		Key:          file.EncryptionKey.ToStringWithAuthVersion(api.AuthVersion),
-		LastModified: int(file.LastModified.UnixMilli()),
-		Created:      int(file.Created.UnixMilli()),
-		Hash:         file.Hash,
-	}
-	metadataStr, err := json.Marshal(metaData)
+	metadataEncrypted, err := api.encryptFileMetadata(file)
 	if err != nil {
-		return fmt.Errorf("marshal file metadata: %w", err)
+		return err
 	}
-	metadataEncrypted := api.EncryptMeta(string(metadataStr))
 	nameEncrypted := file.EncryptionKey.EncryptMeta(file.Name)
 	nameHashed := api.HashFileName(file.Name)
 
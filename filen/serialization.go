@@ -12,39 +12,46 @@ import (
 )
 
 type SerializableFilen struct {
-	APIKey         string
-	AuthVersion    int
-	Email          string
-	MasterKeys     [][64]byte
-	DEK            [32]byte
-	KEK            [32]byte
-	PrivateKey     []byte
-	HMACKey        [32]byte
-	BaseFolderUUID string
+	APIKey           string
+	AuthVersion      int
+	Email            string
+	MasterKeys       [][64]byte // Deprecated: only read from data serialized by older versions, use MasterKeyStrings
+	MasterKeyStrings []string   // the master keys, which are 40 (v1) or 64 (v2) characters long
+	DEK              [32]byte
+	KEK              [32]byte
+	PrivateKey       []byte
+	HMACKey          [32]byte
+	BaseFolderUUID   string
 }
 
 func (api *Filen) serialize() *SerializableFilen {
-	masterKeys := make([][64]byte, len(api.MasterKeys))
+	masterKeys := make([]string, len(api.MasterKeys))
 	for i, masterKey := range api.MasterKeys {
-		masterKeys[i] = masterKey.Bytes
+		masterKeys[i] = masterKey.Key()
 	}
 	return &SerializableFilen{
-		APIKey:         api.Client.APIKey,
-		AuthVersion:    api.AuthVersion,
-		Email:          api.Email,
-		MasterKeys:     masterKeys,
-		DEK:            api.DEK.Bytes,
-		KEK:            api.KEK.Bytes,
-		PrivateKey:     x509.MarshalPKCS1PrivateKey(&api.PrivateKey),
-		HMACKey:        api.HMACKey,
-		BaseFolderUUID: api.BaseFolder.GetUUID(),
+		APIKey:           api.Client.APIKey,
+		AuthVersion:      api.AuthVersion,
+		Email:            api.Email,
+		MasterKeyStrings: masterKeys,
+		DEK:              api.DEK.Bytes,
+		KEK:              api.KEK.Bytes,
+		PrivateKey:       x509.MarshalPKCS1PrivateKey(&api.PrivateKey),
+		HMACKey:          api.HMACKey,
+		BaseFolderUUID:   api.BaseFolder.GetUUID(),
 	}
 }
 
-func (s *SerializableFilen) deserialize() (*Filen, error) {
-	masterKeys := make([]crypto.MasterKey, len(s.MasterKeys))
-	for i, masterKey := range s.MasterKeys {
-		masterKey, err := crypto.NewMasterKey(masterKey)
+func (s *SerializableFilen) deserialize(opts client.Options) (*Filen, error) {
+	masterKeyStrings := s.MasterKeyStrings
+	if len(masterKeyStrings) == 0 {
+		for _, masterKey := range s.MasterKeys {
+			masterKeyStrings = append(masterKeyStrings, string(masterKey[:]))
+		}
+	}
+	masterKeys := make([]crypto.MasterKey, len(masterKeyStrings))
+	for i, masterKey := range masterKeyStrings {
+		masterKey, err := crypto.NewMasterKeyFromString(masterKey)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse master key: %w", err)
 		}
@@ -73,7 +80,7 @@ func (s *SerializableFilen) deserialize() (*Filen, error) {
 	}
 
 	return &Filen{
-		Client:      client.NewWithAPIKey(context.Background(), s.APIKey),
+		Client:      client.NewWithOptions(context.Background(), opts).Authorize(s.APIKey),
 		AuthVersion: s.AuthVersion,
 		Email:       s.Email,
 		MasterKeys:  masterKeys,
@@ -93,10 +100,15 @@ func (api *Filen) SerializeTo(w io.Writer) error {
 }
 
 func DeserializeFrom(r io.Reader) (*Filen, error) {
+	return DeserializeFromWithOptions(r, client.Options{})
+}
+
+// DeserializeFromWithOptions is like [DeserializeFrom], but the restored Filen uses a client configured by opts.
+func DeserializeFromWithOptions(r io.Reader, opts client.Options) (*Filen, error) {
 	var s SerializableFilen
 	decoder := gob.NewDecoder(r)
 	if err := decoder.Decode(&s); err != nil {
 		return nil, err
 	}
-	return s.deserialize()
+	return s.deserialize(opts)
 }
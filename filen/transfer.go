@@ -0,0 +1,94 @@
+package filen
+
+import (
+	"context"
+	"fmt"
+	"golang.org/x/sync/semaphore"
+)
+
+// TransferOptions configures the concurrency and memory use of file transfers.
+// Zero values select the defaults.
+type TransferOptions struct {
+	// UploadParallelism is the number of chunks of a file which are uploaded concurrently.
+	// Defaults to [MaxUploaders].
+	UploadParallelism int
+	// DownloadReadAhead is the number of chunks a download reader fetches ahead. Defaults to [MaxBufferSize].
+	DownloadReadAhead int
+	// MemoryBudget limits the bytes of chunk buffers held by all transfers of a Filen together.
+	// Transfers wait for memory when the budget is used up, and download readers read ahead less.
+	// A transfer always needs at least one chunk, so the budget is rounded up to [ChunkSize].
+	// Defaults to unlimited.
+	MemoryBudget int64
+}
+
+// SetTransferOptions configures the transfers of the Filen, see also [Options.Transfers].
+// Running transfers are not affected, and it must not be called concurrently with starting a transfer.
+func (api *Filen) SetTransferOptions(opts TransferOptions) {
+	api.transferOptions = opts
+	api.memory = nil
+	if opts.MemoryBudget > 0 {
+		api.memory = &memoryBudget{sem: semaphore.NewWeighted(max(opts.MemoryBudget, ChunkSize))}
+	}
+}
+
+// TransferOptions returns the options set with [Filen.SetTransferOptions].
+func (api *Filen) TransferOptions() TransferOptions {
+	return api.transferOptions
+}
+
+type transferOptionsKey struct{}
+
+// WithTransferOptions returns a context which overrides the Filen's transfer options for transfers started with it.
+// Only non-zero fields are overridden. MemoryBudget is ignored, as the budget is shared by all transfers.
+func WithTransferOptions(ctx context.Context, opts TransferOptions) context.Context {
+	return context.WithValue(ctx, transferOptionsKey{}, opts)
+}
+
+// transferOptionsFor returns the options for a transfer started with ctx, with defaults filled in.
+func (api *Filen) transferOptionsFor(ctx context.Context) TransferOptions {
+	opts := api.transferOptions
+	if override, ok := ctx.Value(transferOptionsKey{}).(TransferOptions); ok {
+		if override.UploadParallelism > 0 {
+			opts.UploadParallelism = override.UploadParallelism
+		}
+		if override.DownloadReadAhead > 0 {
+			opts.DownloadReadAhead = override.DownloadReadAhead
+		}
+	}
+	if opts.UploadParallelism <= 0 {
+		opts.UploadParallelism = MaxUploaders
+	}
+	if opts.DownloadReadAhead <= 0 {
+		opts.DownloadReadAhead = MaxBufferSize
+	}
+	return opts
+}
+
+// memoryBudget limits the chunk buffers held by transfers. A nil *memoryBudget is unlimited.
+// Transfers keep the budget they started with, so that the options can be changed while they run.
+type memoryBudget struct {
+	sem *semaphore.Weighted
+}
+
+// reserve waits until the memory for n chunk buffers is available.
+func (m *memoryBudget) reserve(ctx context.Context, n int) error {
+	if m == nil || n == 0 {
+		return nil
+	}
+	if err := m.sem.Acquire(ctx, int64(n)*ChunkSize); err != nil {
+		return fmt.Errorf("reserve memory: %w", context.Cause(ctx))
+	}
+	return nil
+}
+
+// tryReserve reserves the memory for n chunk buffers if it is available right away.
+func (m *memoryBudget) tryReserve(n int) bool {
+	return m == nil || m.sem.TryAcquire(int64(n)*ChunkSize)
+}
+
+// release returns the memory for n chunk buffers.
+func (m *memoryBudget) release(n int) {
+	if m != nil && n > 0 {
+		m.sem.Release(int64(n) * ChunkSize)
+	}
+}
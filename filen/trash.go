@@ -0,0 +1,71 @@
+package filen
+
+import (
+	"context"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/client"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+)
+
+// ListTrash fetches the files and directories in the trash.
+// Only items which were trashed themselves are listed, not the contents of trashed directories.
+// The ParentUUID of each item is the directory it was trashed from.
+func (api *Filen) ListTrash(ctx context.Context) ([]*types.File, []*types.Directory, error) {
+	return api.ListTrashWithOptions(ctx, ReadDirectoryOptions{})
+}
+
+// ListTrashWithOptions is like [Filen.ListTrash], configured by opts.
+func (api *Filen) ListTrashWithOptions(ctx context.Context, opts ReadDirectoryOptions) ([]*types.File, []*types.Directory, error) {
+	files, directories, err := api.readDirectory(ctx, client.TrashUUID, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list trash: %w", err)
+	}
+	return files, directories, nil
+}
+
+// RestoreFile restores a file from the trash to the directory it was trashed from.
+func (api *Filen) RestoreFile(ctx context.Context, file *types.File) error {
+	err := api.Client.PostV3FileRestore(ctx, file.UUID)
+	if err != nil {
+		return fmt.Errorf("restore file: %w", err)
+	}
+	return nil
+}
+
+// RestoreDirectory restores a directory (including its contents) from the trash to the directory it was trashed from.
+func (api *Filen) RestoreDirectory(ctx context.Context, dir *types.Directory) error {
+	err := api.Client.PostV3DirRestore(ctx, dir.UUID)
+	if err != nil {
+		return fmt.Errorf("restore directory: %w", err)
+	}
+	return nil
+}
+
+// DeleteFilePermanently deletes a file, which may or may not be in the trash. This cannot be undone.
+func (api *Filen) DeleteFilePermanently(ctx context.Context, file *types.File) error {
+	err := api.Client.PostV3FileDeletePermanent(ctx, file.UUID)
+	if err != nil {
+		return fmt.Errorf("delete file permanently: %w", err)
+	}
+	return nil
+}
+
+// DeleteDirectoryPermanently deletes a directory and its contents, which may or may not be in the trash.
+// This cannot be undone.
+func (api *Filen) DeleteDirectoryPermanently(ctx context.Context, dir *types.Directory) error {
+	err := api.Client.PostV3DirDeletePermanent(ctx, dir.UUID)
+	if err != nil {
+		return fmt.Errorf("delete directory permanently: %w", err)
+	}
+	api.pathCache.removeDirectory(dir.UUID)
+	return nil
+}
+
+// EmptyTrash permanently deletes all items in the trash. This cannot be undone.
+func (api *Filen) EmptyTrash(ctx context.Context) error {
+	err := api.Client.PostV3TrashEmpty(ctx)
+	if err != nil {
+		return fmt.Errorf("empty trash: %w", err)
+	}
+	return nil
+}
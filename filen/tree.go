@@ -0,0 +1,207 @@
+package filen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+	"io/fs"
+	"iter"
+	"path"
+	"sort"
+	"strings"
+)
+
+// A DirectoryTree holds all files and directories below a directory, see [Filen.ReadDirectoryTree].
+type DirectoryTree struct {
+	Root *TreeNode // the node of the directory the tree was read from
+}
+
+// A TreeNode is a file or a directory in a [DirectoryTree].
+type TreeNode struct {
+	Path      string                   // the path relative to the tree's root, separated by slashes, or "." for the root
+	File      *types.File              // the file, or nil for a directory
+	Directory types.DirectoryInterface // the directory, or nil for a file
+	Parent    *TreeNode                // the parent directory, or nil for the root
+	Children  []*TreeNode              // the contents of a directory, sorted by name
+}
+
+// IsDir reports whether the node is a directory.
+func (n *TreeNode) IsDir() bool {
+	return n.File == nil
+}
+
+// Name returns the name of the file or directory.
+func (n *TreeNode) Name() string {
+	if n.File != nil {
+		return n.File.Name
+	}
+	return n.Directory.GetName()
+}
+
+// UUID returns the UUID of the file or directory.
+func (n *TreeNode) UUID() string {
+	if n.File != nil {
+		return n.File.UUID
+	}
+	return n.Directory.GetUUID()
+}
+
+// ReadDirectoryTree lists all files and directories below dir with a single request.
+// It fails if the metadata of any entry cannot be decrypted; see [Filen.ReadDirectoryTreeWithOptions] to skip those.
+func (api *Filen) ReadDirectoryTree(ctx context.Context, dir types.DirectoryInterface) (*DirectoryTree, error) {
+	return api.ReadDirectoryTreeWithOptions(ctx, dir, ReadDirectoryOptions{})
+}
+
+// ReadDirectoryTreeWithOptions is like [Filen.ReadDirectoryTree], configured by opts.
+// The contents of skipped directories are left out as well.
+// It fails if the listing contains an item whose parent directory is neither listed nor skipped.
+func (api *Filen) ReadDirectoryTreeWithOptions(ctx context.Context, dir types.DirectoryInterface, opts ReadDirectoryOptions) (*DirectoryTree, error) {
+	skipped := make(map[string]bool)
+	onSkip := opts.OnSkip
+	opts.OnSkip = func(err *ItemDecryptionError) {
+		if err.Directory {
+			skipped[err.UUID] = true
+		}
+		if onSkip != nil {
+			onSkip(err)
+		}
+	}
+	files, directories, err := api.readDirectoryTree(ctx, dir.GetUUID(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return newDirectoryTree(dir, files, directories, skipped)
+}
+
+// newDirectoryTree arranges the listed files and directories below root.
+// Items inside the skipped directories are left out, any other item with an unknown parent is an error.
+func newDirectoryTree(root types.DirectoryInterface, files []*types.File, directories []*types.Directory, skipped map[string]bool) (*DirectoryTree, error) {
+	rootNode := &TreeNode{Path: ".", Directory: root}
+	nodes := map[string]*TreeNode{root.GetUUID(): rootNode}
+	for _, d := range directories {
+		nodes[d.UUID] = &TreeNode{Directory: d}
+	}
+	for _, d := range directories {
+		parent, ok := nodes[d.ParentUUID]
+		if !ok {
+			if skipped[d.ParentUUID] {
+				continue
+			}
+			return nil, fmt.Errorf("read directory tree: directory %s has an unknown parent %s", d.UUID, d.ParentUUID)
+		}
+		parent.Children = append(parent.Children, nodes[d.UUID])
+	}
+	for _, f := range files {
+		parent, ok := nodes[f.ParentUUID]
+		if !ok {
+			if skipped[f.ParentUUID] {
+				continue
+			}
+			return nil, fmt.Errorf("read directory tree: file %s has an unknown parent %s", f.UUID, f.ParentUUID)
+		}
+		parent.Children = append(parent.Children, &TreeNode{File: f})
+	}
+
+	// the contents of skipped directories are not reachable from the root
+	queue := []*TreeNode{rootNode}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		sort.Slice(node.Children, func(i, j int) bool {
+			return node.Children[i].Name() < node.Children[j].Name()
+		})
+		for _, child := range node.Children {
+			child.Parent = node
+			child.Path = path.Join(node.Path, child.Name())
+			if child.IsDir() {
+				queue = append(queue, child)
+			}
+		}
+	}
+	return &DirectoryTree{Root: rootNode}, nil
+}
+
+// Lookup returns the node at the slash-separated path relative to the tree's root, or nil if there is none.
+// Like [Filen.FindItem], a file is preferred over a directory with the same name.
+func (t *DirectoryTree) Lookup(p string) *TreeNode {
+	node := t.Root
+	for _, segment := range strings.Split(p, "/") {
+		if segment == "" || segment == "." {
+			continue
+		}
+		if !node.IsDir() {
+			return nil
+		}
+		var next *TreeNode
+		for _, child := range node.Children {
+			if child.Name() == segment && (next == nil || !child.IsDir()) {
+				next = child
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// Walk calls fn for every node in the tree in lexical order, starting with the root, like [fs.WalkDir].
+// If fn returns [fs.SkipDir] for a directory, its contents are skipped; for a file, the remaining
+// contents of its directory are skipped. If fn returns [fs.SkipAll], the walk stops.
+// Any other error stops the walk and is returned.
+func (t *DirectoryTree) Walk(fn func(node *TreeNode) error) error {
+	err := walkTree(t.Root, fn)
+	if errors.Is(err, fs.SkipDir) || errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+func walkTree(node *TreeNode, fn func(node *TreeNode) error) error {
+	if err := fn(node); err != nil {
+		return err
+	}
+	for _, child := range node.Children {
+		err := walkTree(child, fn)
+		if errors.Is(err, fs.SkipDir) {
+			if child.IsDir() {
+				continue
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// All returns an iterator over all nodes in the tree in the order of [DirectoryTree.Walk].
+func (t *DirectoryTree) All() iter.Seq[*TreeNode] {
+	return func(yield func(*TreeNode) bool) {
+		_ = t.Walk(func(node *TreeNode) error {
+			if !yield(node) {
+				return fs.SkipAll
+			}
+			return nil
+		})
+	}
+}
+
+// Stats counts the files and directories in the tree, excluding the root, and sums up the file sizes.
+func (t *DirectoryTree) Stats() DirectoryStats {
+	stats := DirectoryStats{}
+	for node := range t.All() {
+		switch {
+		case node == t.Root:
+		case node.IsDir():
+			stats.Directories++
+		default:
+			stats.Files++
+			stats.Size += int64(node.File.Size)
+		}
+	}
+	return stats
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
 	"hash"
 	"io"
+	"os"
 	"strconv"
 	"sync"
 )
@@ -156,22 +157,38 @@ func (api *Filen) completeUploadEmpty(fu *FileUpload) (*types.File, error) {
 
 }
 
+// UploadFile uploads the data read from r as a file. An existing file with the same name is handled
+// according to the conflict policy, see [WithConflictPolicy].
 func (api *Filen) UploadFile(ctx context.Context, file *types.IncompleteFile, r io.Reader) (*types.File, error) {
+	file, existing, err := api.resolveFileConflict(ctx, file)
+	if err != nil || existing != nil {
+		return existing, err
+	}
 	ctx, cancel := context.WithCancelCause(ctx)
 	defer cancel(nil) // Ensure context is canceled when we exit
 
 	fileUpload := api.newFileUpload(ctx, cancel, file)
-	uploadSem := make(chan struct{}, MaxUploaders)
+	progress := newProgressTracker(ctx, file.UUID, file.Name, true, -1, -1)
+	memory := api.memory
+	uploadSem := make(chan struct{}, api.transferOptionsFor(ctx).UploadParallelism)
 	wg := sync.WaitGroup{}
 	bucketAndRegion := make(chan client.V3UploadResponse, 1)
 	size := 0
 
 	for i := 0; ; i++ {
+		if err := memory.reserve(ctx, 1); err != nil {
+			return nil, err
+		}
 		data := make([]byte, ChunkSize, ChunkSize+file.EncryptionKey.Cipher.Overhead())
-		read, err := r.Read(data)
+		// chunks have to be full except for the last one, even if r returns less per Read
+		read, err := io.ReadFull(r, data)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
 		size += read
 
 		if err != nil && err != io.EOF {
+			memory.release(1)
 			fileUpload.cancel(fmt.Errorf("read chunk %d: %w", i, err))
 			return nil, err
 		}
@@ -184,11 +201,13 @@ func (api *Filen) UploadFile(ctx context.Context, file *types.IncompleteFile, r
 
 			select {
 			case <-ctx.Done():
+				memory.release(1)
 				return nil, fmt.Errorf("context done %w", context.Cause(ctx))
 			case uploadSem <- struct{}{}:
 				wg.Add(1)
 				go func(i int, chunk []byte) {
 					defer func() {
+						memory.release(1)
 						<-uploadSem
 						wg.Done()
 					}()
@@ -198,12 +217,15 @@ func (api *Filen) UploadFile(ctx context.Context, file *types.IncompleteFile, r
 						cancel(err)
 						return
 					}
+					progress.chunkDone(i, read)
 					select { // only care about getting this once
 					case bucketAndRegion <- *resp:
 					default:
 					}
 				}(i, data)
 			}
+		} else {
+			memory.release(1)
 		}
 
 		if err == io.EOF {
@@ -235,3 +257,32 @@ func (api *Filen) UploadFile(ctx context.Context, file *types.IncompleteFile, r
 
 	}
 }
+
+// UploadFromReaderAt uploads size bytes read from r. Unlike [Filen.UploadFile], chunks are read concurrently,
+// which is faster for local files. Use [Filen.NewUploadSession] and [Filen.ResumeUpload] to be able to
+// resume a failed upload.
+func (api *Filen) UploadFromReaderAt(ctx context.Context, file *types.IncompleteFile, r io.ReaderAt, size int64) (*types.File, error) {
+	return api.ResumeUpload(ctx, api.NewUploadSession(file, size), r)
+}
+
+// UploadLocalFile uploads the file at path on the local file system to the parent directory,
+// keeping its name and timestamps.
+func (api *Filen) UploadLocalFile(ctx context.Context, path string, parent types.DirectoryInterface) (*types.File, error) {
+	osFile, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer func() { _ = osFile.Close() }()
+	stat, err := osFile.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+	if stat.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	file, err := types.NewIncompleteFileFromOSFile(api.AuthVersion, osFile, parent)
+	if err != nil {
+		return nil, err
+	}
+	return api.UploadFromReaderAt(ctx, file, osFile, stat.Size())
+}
@@ -0,0 +1,203 @@
+package filen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultDirectoryParallelism is the default number of files transferred concurrently by
+// [Filen.UploadDirectory] and [Filen.DownloadDirectory].
+const DefaultDirectoryParallelism = 4
+
+// UploadDirectoryOptions configures [Filen.UploadDirectory].
+type UploadDirectoryOptions struct {
+	// FS, if set, is read instead of the local file system, and the local path is a path within it.
+	FS fs.FS
+	// Include, if not empty, restricts the upload to files matching at least one of the patterns.
+	// Patterns use the syntax of [path.Match]. Patterns containing a slash match the path relative
+	// to the uploaded directory, other patterns match the file name.
+	Include []string
+	// Exclude skips files and directories matching any of the patterns, in the same syntax as Include.
+	Exclude []string
+	// Parallelism is the number of files uploaded concurrently, defaults to [DefaultDirectoryParallelism].
+	Parallelism int
+}
+
+// An UploadResult is the outcome of uploading a single file of a directory.
+type UploadResult struct {
+	Path string      // the path relative to the uploaded directory, separated by slashes
+	File *types.File // the uploaded file, or nil if the upload failed
+	Err  error       // why the upload failed
+}
+
+// matchesAny reports whether the slash-separated relative path matches one of the patterns.
+func matchesAny(patterns []string, relativePath string) bool {
+	for _, pattern := range patterns {
+		name := relativePath
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(relativePath)
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePatterns checks that all patterns are well-formed.
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+type directoryUploadJob struct {
+	relativePath string
+	parent       types.DirectoryInterface
+}
+
+// UploadDirectory uploads the contents of the local directory at localPath into parent,
+// creating the directory structure and uploading files concurrently. Existing directories are reused,
+// while existing files are handled according to the conflict policy, see [WithConflictPolicy].
+// Files keep their modification times. Symbolic links to files are uploaded as the files they point to,
+// while symbolic links to directories and dangling links are skipped.
+//
+// A result is returned for every file, in the order of a walk of the directory. If any file could not be
+// uploaded, the returned error joins the failures. If the directory structure could not be created,
+// the upload stops and only the error is returned.
+func (api *Filen) UploadDirectory(ctx context.Context, localPath string, parent types.DirectoryInterface, opts UploadDirectoryOptions) ([]UploadResult, error) {
+	if err := errors.Join(validatePatterns(opts.Include), validatePatterns(opts.Exclude)); err != nil {
+		return nil, err
+	}
+	fsys, root := opts.FS, localPath
+	if fsys == nil {
+		fsys, root = os.DirFS(localPath), "."
+	}
+
+	// create the directories first, so that files can be uploaded in any order
+	dirCtx := WithConflictPolicy(ctx, ConflictOverwrite)
+	jobs := make([]directoryUploadJob, 0)
+	directories := map[string]types.DirectoryInterface{root: parent}
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		relativePath := strings.TrimPrefix(p, root+"/")
+		if root == "." {
+			relativePath = p
+		}
+		if matchesAny(opts.Exclude, relativePath) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		dirParent := directories[path.Dir(p)]
+		if d.IsDir() {
+			dir, err := api.CreateDirectory(dirCtx, dirParent, d.Name())
+			if err != nil {
+				return fmt.Errorf("create directory %s: %w", relativePath, err)
+			}
+			directories[p] = dir
+			return nil
+		}
+		if len(opts.Include) > 0 && !matchesAny(opts.Include, relativePath) {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			// follow symbolic links to files, but skip links to directories and dangling links
+			stat, err := fs.Stat(fsys, p)
+			if err != nil || !stat.Mode().IsRegular() {
+				return nil
+			}
+		} else if !d.Type().IsRegular() {
+			// skip devices, sockets etc.
+			return nil
+		}
+		jobs = append(jobs, directoryUploadJob{relativePath: relativePath, parent: dirParent})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", localPath, err)
+	}
+
+	results := make([]UploadResult, len(jobs))
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = DefaultDirectoryParallelism
+	}
+	sem := make(chan struct{}, parallelism)
+	wg := sync.WaitGroup{}
+	for i, job := range jobs {
+		results[i].Path = job.relativePath
+		select {
+		case <-ctx.Done():
+			results[i].Err = context.Cause(ctx)
+			continue
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			var file *types.File
+			var err error
+			if opts.FS == nil {
+				file, err = api.UploadLocalFile(ctx, filepath.Join(localPath, filepath.FromSlash(job.relativePath)), job.parent)
+			} else {
+				file, err = api.uploadFSFile(ctx, fsys, path.Join(root, job.relativePath), job.parent)
+			}
+			results[i].File, results[i].Err = file, err
+		}()
+	}
+	wg.Wait()
+
+	errs := make([]error, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("upload %s: %w", result.Path, result.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// uploadFSFile uploads the file at name in fsys to parent, keeping its modification time.
+func (api *Filen) uploadFSFile(ctx context.Context, fsys fs.FS, name string, parent types.DirectoryInterface) (*types.File, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+	if stat.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", name)
+	}
+	file, err := types.NewIncompleteFile(api.AuthVersion, stat.Name(), "", stat.ModTime(), stat.ModTime(), parent)
+	if err != nil {
+		return nil, err
+	}
+	if readerAt, ok := f.(io.ReaderAt); ok {
+		return api.UploadFromReaderAt(ctx, file, readerAt, stat.Size())
+	}
+	return api.UploadFile(ctx, file, f)
+}
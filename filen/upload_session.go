@@ -0,0 +1,303 @@
+package filen
+
+import (
+	"context"
+	"encoding"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/client"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+	"hash"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// An UploadSession holds the state of an upload, so that it can be continued with [Filen.ResumeUpload]
+// after it failed or the process was restarted. All exported fields are persisted by [UploadSession.SerializeTo],
+// and the session can also be stored with e.g. encoding/json.
+//
+// The session contains the file's encryption key and needs to be stored as securely as the file itself.
+type UploadSession struct {
+	UUID         string // the UUID of the file being uploaded
+	Name         string
+	MimeType     string
+	Key          string // the file's encryption key, as stored in the file metadata
+	Created      time.Time
+	LastModified time.Time
+	ParentUUID   string
+	UploadKey    string // identifies the upload to the API
+	Size         int64  // the file size in bytes
+
+	Uploaded     []bool // which chunks have been uploaded
+	HashedChunks int    // how many chunks from the start of the file are included in HashState
+	HashState    []byte // the marshalled state of the SHA-512 hash of the file, which is stored in the metadata
+	Bucket       string // the storage bucket reported when uploading a chunk
+	Region       string // the storage region reported when uploading a chunk
+
+	// OnProgress, if set, is called after each uploaded chunk, e.g. to persist the session.
+	// It may be called concurrently.
+	OnProgress func(session *UploadSession) `json:"-"`
+
+	mu sync.Mutex
+}
+
+// NewUploadSession starts an upload of size bytes, which can then be uploaded with [Filen.ResumeUpload].
+func (api *Filen) NewUploadSession(file *types.IncompleteFile, size int64) *UploadSession {
+	chunks := (size + ChunkSize - 1) / ChunkSize
+	return &UploadSession{
+		UUID:         file.UUID,
+		Name:         file.Name,
+		MimeType:     file.MimeType,
+		Key:          file.EncryptionKey.ToStringWithAuthVersion(api.AuthVersion),
+		Created:      file.Created,
+		LastModified: file.LastModified,
+		ParentUUID:   file.ParentUUID,
+		UploadKey:    crypto.GenerateRandomString(32),
+		Size:         size,
+		Uploaded:     make([]bool, chunks),
+	}
+}
+
+// RemainingChunks returns the number of chunks which have not been uploaded yet.
+func (s *UploadSession) RemainingChunks() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remaining := 0
+	for _, uploaded := range s.Uploaded {
+		if !uploaded {
+			remaining++
+		}
+	}
+	return remaining
+}
+
+// SerializeTo writes the session to w, so that it can be restored with [DeserializeUploadSessionFrom].
+func (s *UploadSession) SerializeTo(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return gob.NewEncoder(w).Encode(s)
+}
+
+// DeserializeUploadSessionFrom restores a session written by [UploadSession.SerializeTo].
+func DeserializeUploadSessionFrom(r io.Reader) (*UploadSession, error) {
+	s := &UploadSession{}
+	if err := gob.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *UploadSession) incompleteFile() (*types.IncompleteFile, error) {
+	key, err := crypto.MakeEncryptionKeyFromUnknownStr(s.Key)
+	if err != nil {
+		return nil, fmt.Errorf("parse encryption key: %w", err)
+	}
+	return &types.IncompleteFile{
+		UUID:          s.UUID,
+		Name:          s.Name,
+		MimeType:      s.MimeType,
+		EncryptionKey: *key,
+		Created:       s.Created,
+		LastModified:  s.LastModified,
+		ParentUUID:    s.ParentUUID,
+	}, nil
+}
+
+// chunkHashed records that the hash state includes the chunk.
+func (s *UploadSession) chunkHashed(chunkIndex int, hasher encoding.BinaryMarshaler) error {
+	state, err := hasher.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("marshal hash state: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.HashedChunks = chunkIndex + 1
+	s.HashState = state
+	return nil
+}
+
+// chunkUploaded records that the chunk was uploaded.
+func (s *UploadSession) chunkUploaded(chunkIndex int, response *client.V3UploadResponse) {
+	s.mu.Lock()
+	s.Uploaded[chunkIndex] = true
+	s.Bucket = response.Bucket
+	s.Region = response.Region
+	s.mu.Unlock()
+	if s.OnProgress != nil {
+		s.OnProgress(s)
+	}
+}
+
+// chunkLength returns the size of the chunk at chunkIndex.
+func (s *UploadSession) chunkLength(chunkIndex int) int64 {
+	return min(ChunkSize, s.Size-int64(chunkIndex)*ChunkSize)
+}
+
+// readChunk reads the chunk at chunkIndex from r, leaving room for the encryption overhead.
+func (s *UploadSession) readChunk(r io.ReaderAt, chunkIndex int, overhead int) ([]byte, error) {
+	offset := int64(chunkIndex) * ChunkSize
+	length := int(s.chunkLength(chunkIndex))
+	data := make([]byte, length, length+overhead)
+	read, err := r.ReadAt(data, offset)
+	if read == length {
+		// ReaderAt may return io.EOF together with the last bytes
+		return data, nil
+	}
+	if err == nil || errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return nil, fmt.Errorf("read chunk %d: %w", chunkIndex, err)
+}
+
+// orderedHasher hashes chunks which are read concurrently in the order of their indices.
+type orderedHasher struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	next    int // the index of the next chunk to be hashed
+	session *UploadSession
+	hasher  hash.Hash
+}
+
+func newOrderedHasher(ctx context.Context, session *UploadSession, hasher hash.Hash, next int) *orderedHasher {
+	h := &orderedHasher{next: next, session: session, hasher: hasher}
+	h.cond = sync.NewCond(&h.mu)
+	// wake up waiting writers when the upload is cancelled
+	context.AfterFunc(ctx, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.cond.Broadcast()
+	})
+	return h
+}
+
+// write waits until all previous chunks have been hashed, then hashes the chunk and records it in the session.
+func (h *orderedHasher) write(ctx context.Context, chunkIndex int, data []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for h.next != chunkIndex {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		h.cond.Wait()
+	}
+	h.hasher.Write(data)
+	if err := h.session.chunkHashed(chunkIndex, h.hasher.(encoding.BinaryMarshaler)); err != nil {
+		return err
+	}
+	h.next++
+	h.cond.Broadcast()
+	return nil
+}
+
+// ResumeUpload uploads the chunks of the session which have not been uploaded yet, reading them from r,
+// and then completes the upload. r must provide the same content as for previous attempts.
+// The session is updated as chunks are uploaded, so it can be persisted if the upload fails.
+//
+// Chunks are read, encrypted and uploaded concurrently, while the file hash is still computed in order.
+// An existing file with the same name is handled according to the conflict policy, see [WithConflictPolicy].
+func (api *Filen) ResumeUpload(ctx context.Context, session *UploadSession, r io.ReaderAt) (*types.File, error) {
+	file, err := session.incompleteFile()
+	if err != nil {
+		return nil, err
+	}
+	file, existing, err := api.resolveFileConflict(ctx, file)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	fileUpload := api.newFileUpload(ctx, cancel, file)
+	fileUpload.uploadKey = session.UploadKey
+	session.mu.Lock()
+	hashedChunks, hashState := session.HashedChunks, session.HashState
+	uploaded := append([]bool(nil), session.Uploaded...)
+	session.mu.Unlock()
+	if len(hashState) > 0 {
+		if err = fileUpload.hasher.(encoding.BinaryUnmarshaler).UnmarshalBinary(hashState); err != nil {
+			return nil, fmt.Errorf("restore hash state: %w", err)
+		}
+	}
+
+	if session.Size == 0 {
+		return api.completeUploadEmpty(fileUpload)
+	}
+
+	progress := newProgressTracker(ctx, file.UUID, file.Name, true, len(uploaded), session.Size)
+	for i, done := range uploaded {
+		if done {
+			progress.skip(1, session.chunkLength(i))
+		}
+	}
+
+	hasher := newOrderedHasher(ctx, session, fileUpload.hasher, hashedChunks)
+	uploadChunk := func(i int) error {
+		// chunks need to be read for hashing even if they have been uploaded already
+		if uploaded[i] && i < hashedChunks {
+			return nil
+		}
+		data, err := session.readChunk(r, i, file.EncryptionKey.Cipher.Overhead())
+		if err != nil {
+			return err
+		}
+		if i >= hashedChunks {
+			if err = hasher.write(ctx, i, data); err != nil {
+				return err
+			}
+		}
+		if uploaded[i] {
+			return nil
+		}
+		size := len(data)
+		resp, err := api.uploadChunk(fileUpload, i, data)
+		if err != nil {
+			return err
+		}
+		session.chunkUploaded(i, resp)
+		progress.chunkDone(i, size)
+		return nil
+	}
+
+	memory := api.memory
+	nextChunk := atomic.Int64{}
+	wg := sync.WaitGroup{}
+	for range min(api.transferOptionsFor(ctx).UploadParallelism, len(uploaded)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				// memory is reserved before claiming a chunk, so that the lowest claimed chunk
+				// can always be hashed without waiting for memory held by later chunks
+				if err := memory.reserve(ctx, 1); err != nil {
+					cancel(err)
+					return
+				}
+				i := int(nextChunk.Add(1) - 1)
+				if i >= len(uploaded) {
+					memory.release(1)
+					return
+				}
+				err := uploadChunk(i)
+				memory.release(1)
+				if err != nil {
+					cancel(err)
+					return
+				}
+			}
+		}()
+	}
+	// wait for running chunk uploads, so that the session is up-to-date when returning
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("upload: %w", context.Cause(ctx))
+	}
+
+	session.mu.Lock()
+	bucket, region := session.Bucket, session.Region
+	session.mu.Unlock()
+	return api.completeUpload(fileUpload, bucket, region, int(session.Size))
+}
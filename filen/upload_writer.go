@@ -0,0 +1,81 @@
+package filen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+	"io"
+)
+
+// ErrUploadAborted is the cause of the failure of an upload cancelled by [UploadWriter.Abort].
+var ErrUploadAborted = errors.New("upload aborted")
+
+// An UploadWriter uploads the data written to it as a file, see [Filen.NewUploadWriter].
+// Chunks are encrypted and uploaded as soon as they are filled, so Write blocks while the upload is behind.
+type UploadWriter struct {
+	pipe   *io.PipeWriter
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+	file   *types.File // set when the upload completed
+	err    error       // set when the upload failed
+}
+
+// NewUploadWriter starts an upload of the data written to the returned writer.
+// The upload is completed by [UploadWriter.Close], which must be called even when the writer is not used further,
+// or cancelled by [UploadWriter.Abort].
+func (api *Filen) NewUploadWriter(ctx context.Context, file *types.IncompleteFile) (*UploadWriter, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("start upload: %w", context.Cause(ctx))
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	pipeReader, pipeWriter := io.Pipe()
+	w := &UploadWriter{
+		pipe:   pipeWriter,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(w.done)
+		w.file, w.err = api.UploadFile(ctx, file, pipeReader)
+		if w.err != nil {
+			// unblock and fail further writes
+			_ = pipeReader.CloseWithError(w.err)
+			return
+		}
+		// with ConflictSkip, nothing was read
+		_, _ = io.Copy(io.Discard, pipeReader)
+	}()
+	return w, nil
+}
+
+// Write writes data to the file. It fails if the upload failed or was aborted.
+func (w *UploadWriter) Write(p []byte) (int, error) {
+	return w.pipe.Write(p)
+}
+
+// Close completes the upload after all remaining data has been uploaded.
+// The uploaded file is then available from [UploadWriter.File].
+func (w *UploadWriter) Close() error {
+	_ = w.pipe.Close()
+	<-w.done
+	w.cancel(nil)
+	return w.err
+}
+
+// Abort cancels the upload. The file is not created, and Close returns an error.
+func (w *UploadWriter) Abort() {
+	w.cancel(ErrUploadAborted)
+	_ = w.pipe.CloseWithError(ErrUploadAborted)
+	<-w.done
+}
+
+// File returns the uploaded file after a successful [UploadWriter.Close], otherwise nil.
+func (w *UploadWriter) File() *types.File {
+	select {
+	case <-w.done:
+		return w.file
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,44 @@
+package filen
+
+import (
+	"context"
+	"fmt"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+	"sort"
+)
+
+// ListFileVersions fetches all versions of a file, including the current one, ordered by upload time (newest first).
+// Filen keeps the previous version when a file with the same name is uploaded to the same directory.
+// Previous versions can be downloaded like any other file and made current with [Filen.RestoreFileVersion].
+func (api *Filen) ListFileVersions(ctx context.Context, file *types.File) ([]*types.File, error) {
+	response, err := api.Client.PostV3FileVersions(ctx, file.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("list file versions: %w", err)
+	}
+
+	versions := response.Versions
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Timestamp > versions[j].Timestamp
+	})
+	files := make([]*types.File, 0, len(versions))
+	for _, version := range versions {
+		versionFile, err := api.newFile(version.UUID, file.ParentUUID, version.Metadata, version.Region, version.Bucket, version.Chunks, false)
+		if err != nil {
+			return nil, fmt.Errorf("list file versions: %w", &ItemDecryptionError{UUID: version.UUID, Err: err})
+		}
+		files = append(files, versionFile)
+	}
+	return files, nil
+}
+
+// RestoreFileVersion makes a previous version (as returned by [Filen.ListFileVersions]) the current version
+// of a file, keeping the current version as a previous one. It returns the restored file.
+func (api *Filen) RestoreFileVersion(ctx context.Context, current *types.File, version *types.File) (*types.File, error) {
+	err := api.Client.PostV3FileVersionRestore(ctx, version.UUID, current.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("restore file version: %w", err)
+	}
+	restored := *version
+	restored.ParentUUID = current.ParentUUID
+	return &restored, nil
+}
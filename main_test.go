@@ -4,23 +4,70 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/sha512"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	sdk "github.com/FilenCloudDienste/filen-sdk-go/filen"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/client"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/crypto"
+	"github.com/FilenCloudDienste/filen-sdk-go/filen/filentest"
 	"github.com/FilenCloudDienste/filen-sdk-go/filen/types"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"io"
+	"io/fs"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"testing/iotest"
 	"time"
 )
 
 var filen *sdk.Filen
 var baseTestDir *types.Directory
 
+var (
+	email    string
+	password string
+	// fakeServer is used instead of the real API if no test account is configured
+	fakeServer *filentest.Server
+)
+
+// newUnauthorizedClient returns a client for the real API, or for fakeServer if it is running.
+func newUnauthorizedClient(ctx context.Context) *client.UnauthorizedClient {
+	if fakeServer != nil {
+		return fakeServer.NewClient(ctx)
+	}
+	return client.New(ctx)
+}
+
+// setupFakeServer starts fakeServer with a new account.
+// The account's auth version can be set with TEST_AUTH_VERSION and defaults to 3.
+func setupFakeServer() error {
+	authVersion := 3
+	if v := os.Getenv("TEST_AUTH_VERSION"); v != "" {
+		var err error
+		authVersion, err = strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("parsing TEST_AUTH_VERSION: %w", err)
+		}
+	}
+	fakeServer = filentest.NewServer()
+	email = "test@example.com"
+	password = "password"
+	return fakeServer.AddAccount(email, password, authVersion)
+}
+
 func setupEnv() error {
 
 	err := godotenv.Load()
@@ -29,16 +76,20 @@ func setupEnv() error {
 		println("Warning: Error loading .env file: ", err.Error())
 	}
 
-	email := os.Getenv("TEST_EMAIL")
-	password := os.Getenv("TEST_PASSWORD")
+	email = os.Getenv("TEST_EMAIL")
+	password = os.Getenv("TEST_PASSWORD")
 	apiKey := os.Getenv("TEST_API_KEY")
 	if email == "" || password == "" {
-		return fmt.Errorf("TEST_EMAIL and TEST_PASSWORD environment variables must be set")
+		println("Warning: TEST_EMAIL and TEST_PASSWORD are not set, testing against a fake server")
+		if err = setupFakeServer(); err != nil {
+			return fmt.Errorf("setting up fake server: %w", err)
+		}
 	}
+	ctx := context.Background()
 	if apiKey == "" {
-		filen, err = sdk.New(context.Background(), email, password)
+		filen, err = sdk.NewWithClient(ctx, email, password, newUnauthorizedClient(ctx))
 	} else {
-		filen, err = sdk.NewWithAPIKey(context.Background(), email, password, apiKey)
+		filen, err = sdk.NewWithAuthorizedClient(ctx, email, password, newUnauthorizedClient(ctx).Authorize(apiKey))
 	}
 	if err != nil {
 		return err
@@ -68,6 +119,9 @@ func cleanupEnv() error {
 	if err != nil {
 		return err
 	}
+	if fakeServer != nil {
+		fakeServer.Close()
+	}
 	return nil
 }
 
@@ -451,7 +505,8 @@ func TestEmptyFileActions(t *testing.T) {
 }
 
 func TestNewWithAPIKey(t *testing.T) {
-	filen2, err := sdk.NewWithAPIKey(context.Background(), os.Getenv("TEST_EMAIL"), os.Getenv("TEST_PASSWORD"), filen.GetAPIKey())
+	ctx := context.Background()
+	filen2, err := sdk.NewWithAuthorizedClient(ctx, email, password, newUnauthorizedClient(ctx).Authorize(filen.GetAPIKey()))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -581,6 +636,55 @@ func TestPartialRead(t *testing.T) {
 	}
 }
 
+func TestPartialReadChunkBoundaries(t *testing.T) {
+	contents := make([]byte, 3*sdk.ChunkSize)
+	_, _ = rand.Read(contents)
+	incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, "partial_read_boundaries.bin", "", time.Now(), time.Now(), baseTestDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := filen.UploadFromReaderAt(context.Background(), incompleteFile, bytes.NewReader(contents), int64(len(contents)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		offset, limit int
+		chunks        int // the number of chunks which should be fetched
+	}{
+		{0, sdk.ChunkSize, 1},
+		{sdk.ChunkSize, 2 * sdk.ChunkSize, 1},
+		{2 * sdk.ChunkSize, 3 * sdk.ChunkSize, 1},
+		{sdk.ChunkSize, 3 * sdk.ChunkSize, 2},
+		{sdk.ChunkSize + 5, 2 * sdk.ChunkSize, 1},
+		{2 * sdk.ChunkSize, -1, 1},
+		{5, 4 * sdk.ChunkSize, 3},
+	} {
+		var fetched atomic.Int32
+		ctx := sdk.WithProgress(context.Background(), func(sdk.Progress) { fetched.Add(1) })
+		reader := filen.GetDownloadReaderWithOffset(ctx, file, tc.offset, tc.limit)
+		downloaded, err := io.ReadAll(reader)
+		_ = reader.Close()
+		if err != nil {
+			t.Fatalf("offset %d, limit %d: %v", tc.offset, tc.limit, err)
+		}
+		end := len(contents)
+		if tc.limit != -1 {
+			end = min(end, tc.limit)
+		}
+		if !bytes.Equal(downloaded, contents[tc.offset:end]) {
+			t.Fatalf("offset %d, limit %d: expected %d bytes, got %d", tc.offset, tc.limit, end-tc.offset, len(downloaded))
+		}
+		if n := fetched.Load(); n != int32(tc.chunks) {
+			t.Fatalf("offset %d, limit %d: expected %d chunks to be fetched, got %d", tc.offset, tc.limit, tc.chunks, n)
+		}
+	}
+
+	if err = filen.TrashFile(context.Background(), *file); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func writeTestData(writer io.Writer, length int) error {
 	data := make([]byte, 0)
 	for i := 0; i < length; i++ {
@@ -653,3 +757,1679 @@ func assertFilesEqual(f1 *os.File, f2 *os.File) (bool, error) {
 		}
 	}
 }
+
+func TestRetries(t *testing.T) {
+	if fakeServer == nil {
+		t.Skip("injecting failures requires the fake server")
+	}
+	ctx := context.Background()
+	clientOptions := fakeServer.ClientOptions()
+	clientOptions.Retry = client.RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	retrying, err := sdk.NewWithOptions(ctx, email, password, sdk.Options{Client: clientOptions, APIKey: filen.GetAPIKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := make([]byte, sdk.ChunkSize*3+100)
+	_, _ = rand.Read(contents)
+	incompleteFile, err := types.NewIncompleteFile(retrying.AuthVersion, "retries.bin", "", time.Now(), time.Now(), baseTestDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeServer.FailRequests(3, http.StatusServiceUnavailable)
+	file, err := retrying.UploadFile(ctx, incompleteFile, bytes.NewReader(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fakeServer.FailRequests(3, http.StatusBadGateway)
+	downloaded, err := io.ReadAll(retrying.GetDownloadReader(ctx, file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(contents, downloaded) {
+		t.Fatal("downloaded contents did not match")
+	}
+
+	fakeServer.FailRequests(4, http.StatusTooManyRequests)
+	_, _, err = retrying.ReadDirectory(ctx, baseTestDir)
+	fakeServer.FailRequests(0, 0)
+	if err == nil {
+		t.Fatal("expected request to fail after all attempts")
+	}
+
+	// a move which was applied before the connection broke is reported as failed instead of being sent again
+	target, err := retrying.CreateDirectory(ctx, baseTestDir, "retries-target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeServer.FailRequestsAfterApplying(1, http.StatusBadGateway)
+	_, err = retrying.MoveFile(ctx, file, target)
+	fakeServer.FailRequests(0, 0)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusBadGateway {
+		t.Fatalf("expected the move to fail without a retry, got %v", err)
+	}
+	moved, err := retrying.GetFile(ctx, file.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.ParentUUID != target.UUID {
+		t.Fatal("expected the move to have been applied once")
+	}
+	file = moved
+
+	// completing an upload is checked after an ambiguous failure, and retried if it was not applied
+	for _, afterApplying := range []bool{true, false} {
+		incompleteFile, err := types.NewIncompleteFile(retrying.AuthVersion, fmt.Sprintf("retries-done-%v.bin", afterApplying), "", time.Now(), time.Now(), target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fakeServer.FailRequestsTo("/v3/upload/done", 1, http.StatusBadGateway, afterApplying)
+		uploaded, err := retrying.UploadFile(ctx, incompleteFile, bytes.NewReader(contents[:100]))
+		fakeServer.FailRequests(0, 0)
+		if err != nil {
+			t.Fatalf("after applying %v: %v", afterApplying, err)
+		}
+		if found, err := retrying.GetFile(ctx, uploaded.UUID); err != nil || found.Size != 100 {
+			t.Fatalf("after applying %v: expected the uploaded file, got %#v, %v", afterApplying, found, err)
+		}
+	}
+
+	// reading is idempotent, so it is retried
+	fakeServer.FailRequestsAfterApplying(1, http.StatusBadGateway)
+	_, _, err = retrying.ReadDirectory(ctx, target)
+	fakeServer.FailRequests(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = retrying.TrashFile(ctx, *file); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	ctx := context.Background()
+	missingFile := types.File{IncompleteFile: types.IncompleteFile{UUID: uuid.NewString()}}
+	err := filen.TrashFile(ctx, missingFile)
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code == "" {
+		t.Fatalf("expected an APIError with a code, got %v", err)
+	}
+
+	if fakeServer == nil {
+		// avoid failed login attempts against real accounts
+		return
+	}
+	_, err = sdk.NewWithClient(ctx, email, "wrong password", newUnauthorizedClient(ctx))
+	if !errors.Is(err, client.ErrWrongCredentials) {
+		t.Fatalf("expected wrong credentials error, got %v", err)
+	}
+}
+
+func TestTwoFactor(t *testing.T) {
+	if fakeServer == nil {
+		t.Skip("requires an account with two-factor authentication on the fake server")
+	}
+	ctx := context.Background()
+	const (
+		tfEmail     = "2fa@example.com"
+		code        = "123456"
+		recoveryKey = "recovery-key"
+	)
+	if err := fakeServer.AddAccount(tfEmail, password, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := fakeServer.EnableTwoFactor(tfEmail, code, recoveryKey); err != nil {
+		t.Fatal(err)
+	}
+	newWithTwoFactor := func(twoFactor sdk.TwoFactor) (*sdk.Filen, error) {
+		return sdk.NewWithOptions(ctx, tfEmail, password, sdk.Options{Client: fakeServer.ClientOptions(), TwoFactor: twoFactor})
+	}
+
+	if _, err := newWithTwoFactor(sdk.TwoFactor{}); !errors.Is(err, client.ErrTwoFactorRequired) {
+		t.Fatalf("expected two-factor code to be required, got %v", err)
+	}
+	if _, err := newWithTwoFactor(sdk.TwoFactor{Code: "654321"}); !errors.Is(err, client.ErrWrongTwoFactorCode) {
+		t.Fatalf("expected wrong two-factor code, got %v", err)
+	}
+	if _, err := newWithTwoFactor(sdk.TwoFactor{Code: code}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newWithTwoFactor(sdk.TwoFactor{RecoveryKey: recoveryKey}); err != nil {
+		t.Fatal(err)
+	}
+
+	prompts := 0
+	_, err := newWithTwoFactor(sdk.TwoFactor{Prompt: func(ctx context.Context) (string, error) {
+		prompts++
+		return code, nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prompts != 1 {
+		t.Fatalf("expected 1 prompt, got %d", prompts)
+	}
+}
+
+func TestAuthVersion1(t *testing.T) {
+	if fakeServer == nil {
+		t.Skip("requires a v1 account on the fake server")
+	}
+	ctx := context.Background()
+	const v1Email = "v1@example.com"
+	if err := fakeServer.AddAccount(v1Email, password, 1); err != nil {
+		t.Fatal(err)
+	}
+	v1Filen, err := sdk.NewWithClient(ctx, v1Email, password, newUnauthorizedClient(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1Filen.AuthVersion != 1 {
+		t.Fatalf("expected auth version 1, got %d", v1Filen.AuthVersion)
+	}
+	if _, err = sdk.NewWithAuthorizedClient(ctx, v1Email, password, v1Filen.Client); err != nil {
+		t.Fatal(err)
+	}
+
+	directory, err := v1Filen.CreateDirectory(ctx, &v1Filen.BaseFolder, "v1 directory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, directories, err := v1Filen.ReadDirectory(ctx, &v1Filen.BaseFolder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(directories) != 1 || directories[0].Name != directory.Name {
+		t.Fatalf("expected to read back the created directory, got %#v", directories)
+	}
+
+	buffer := &bytes.Buffer{}
+	if err = v1Filen.SerializeTo(buffer); err != nil {
+		t.Fatal(err)
+	}
+	deserialized, err := sdk.DeserializeFromWithOptions(buffer, fakeServer.ClientOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	deserialized.Client = v1Filen.Client
+	if !reflect.DeepEqual(v1Filen, deserialized) {
+		t.Fatalf("Filen objects are not equal:\nOriginal:%#v\nDeserialized:%#v\n", v1Filen, deserialized)
+	}
+}
+
+func TestReadDirectorySkipUndecryptable(t *testing.T) {
+	if fakeServer == nil {
+		t.Skip("requires corrupting metadata on the fake server")
+	}
+	ctx := context.Background()
+	parent, err := filen.CreateDirectory(ctx, baseTestDir, "undecryptable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readable, err := filen.CreateDirectory(ctx, parent, "readable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken, err := filen.CreateDirectory(ctx, parent, "broken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fakeServer.SetMetadata(broken.UUID, "garbage"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = filen.ReadDirectory(ctx, parent)
+	var itemErr *sdk.ItemDecryptionError
+	if !errors.As(err, &itemErr) || itemErr.UUID != broken.UUID || !errors.Is(err, crypto.ErrUnsupportedFormat) {
+		t.Fatalf("expected decryption error for %s, got %v", broken.UUID, err)
+	}
+
+	skipped := make([]string, 0)
+	_, directories, err := filen.ReadDirectoryWithOptions(ctx, parent, sdk.ReadDirectoryOptions{
+		SkipUndecryptable: true,
+		OnSkip: func(err *sdk.ItemDecryptionError) {
+			skipped = append(skipped, err.UUID)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(directories) != 1 || directories[0].UUID != readable.UUID {
+		t.Fatalf("expected only the readable directory, got %#v", directories)
+	}
+	if len(skipped) != 1 || skipped[0] != broken.UUID {
+		t.Fatalf("expected the broken directory to be reported, got %v", skipped)
+	}
+
+	// the contents of a skipped directory are left out of the tree as well
+	if _, err = filen.CreateDirectory(ctx, broken, "inside"); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := filen.ReadDirectoryTreeWithOptions(ctx, parent, sdk.ReadDirectoryOptions{SkipUndecryptable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Root.Children) != 1 || tree.Root.Children[0].UUID() != readable.UUID {
+		t.Fatalf("expected only the readable directory in the tree, got %#v", tree.Root.Children)
+	}
+}
+
+func TestMoveAndRename(t *testing.T) {
+	ctx := context.Background()
+	source, err := filen.CreateDirectory(ctx, baseTestDir, "move-source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := filen.CreateDirectory(ctx, baseTestDir, "move-target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, "move.txt", "", time.Now(), time.Now(), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := filen.UploadFile(ctx, incompleteFile, bytes.NewReader([]byte("moving around")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("File", func(t *testing.T) {
+		moved, err := filen.MoveFile(ctx, file, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		renamed, err := filen.RenameFile(ctx, moved, "renamed.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found, err := filen.FindFile(ctx, "go/move-source/move.txt"); err != nil || found != nil {
+			t.Fatalf("expected file to be gone from the source, got %#v, %v", found, err)
+		}
+		found, err := filen.FindFile(ctx, "go/move-target/renamed.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(renamed, found) {
+			t.Fatalf("Renamed \n%#v\n and found \n%#v\n file did not match", renamed, found)
+		}
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		moved, err := filen.MoveDirectory(ctx, target, source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		renamed, err := filen.RenameDirectory(ctx, moved, "renamed-target")
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, err := filen.FindDirectory(ctx, "go/move-source/renamed-target")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(renamed, found) {
+			t.Fatalf("Renamed \n%#v\n and found \n%#v\n directory did not match", renamed, found)
+		}
+		if file, err := filen.FindFile(ctx, "go/move-source/renamed-target/renamed.txt"); err != nil || file == nil {
+			t.Fatalf("expected file to move with its directory, got %v", err)
+		}
+	})
+}
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+	dir, err := filen.CreateDirectory(ctx, baseTestDir, "trash-me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, "trash-me.txt", "", time.Now(), time.Now(), baseTestDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := filen.UploadFile(ctx, incompleteFile, bytes.NewReader([]byte("trash me")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = filen.TrashDirectory(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+	if err = filen.TrashFile(ctx, *file); err != nil {
+		t.Fatal(err)
+	}
+
+	inTrash := func() (bool, bool) {
+		files, directories, err := filen.ListTrash(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileFound, dirFound := false, false
+		for _, f := range files {
+			fileFound = fileFound || f.UUID == file.UUID
+		}
+		for _, d := range directories {
+			dirFound = dirFound || d.UUID == dir.UUID
+		}
+		return fileFound, dirFound
+	}
+	if fileFound, dirFound := inTrash(); !fileFound || !dirFound {
+		t.Fatalf("expected file and directory in trash, found file: %t, directory: %t", fileFound, dirFound)
+	}
+
+	if err = filen.RestoreFile(ctx, file); err != nil {
+		t.Fatal(err)
+	}
+	if err = filen.RestoreDirectory(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+	if fileFound, dirFound := inTrash(); fileFound || dirFound {
+		t.Fatalf("expected file and directory to be restored, found file: %t, directory: %t", fileFound, dirFound)
+	}
+	if found, err := filen.FindFile(ctx, "go/trash-me.txt"); err != nil || found == nil {
+		t.Fatalf("expected restored file to be found, got %v", err)
+	}
+
+	if err = filen.DeleteFilePermanently(ctx, file); err != nil {
+		t.Fatal(err)
+	}
+	if err = filen.DeleteDirectoryPermanently(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := filen.FindItem(ctx, "go/trash-me"); err != nil || found != nil {
+		t.Fatalf("expected directory to be deleted, got %#v, %v", found, err)
+	}
+
+	if fakeServer == nil {
+		// don't empty the trash of a real account
+		return
+	}
+	other, err := filen.CreateDirectory(ctx, baseTestDir, "empty-trash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = filen.TrashDirectory(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if err = filen.EmptyTrash(ctx); err != nil {
+		t.Fatal(err)
+	}
+	files, directories, err := filen.ListTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 || len(directories) != 0 {
+		t.Fatalf("expected empty trash, got %d files and %d directories", len(files), len(directories))
+	}
+}
+
+func TestFileVersions(t *testing.T) {
+	ctx := context.Background()
+	upload := func(content string) *types.File {
+		incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, "versioned.txt", "", time.Now(), time.Now(), baseTestDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := filen.UploadFile(ctx, incompleteFile, bytes.NewReader([]byte(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	download := func(file *types.File) string {
+		content, err := io.ReadAll(filen.GetDownloadReader(ctx, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+	first := upload("first version")
+	current := upload("second version")
+
+	versions, err := filen.ListFileVersions(ctx, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].UUID != current.UUID || versions[1].UUID != first.UUID {
+		t.Fatalf("expected the current and the first version, got %#v", versions)
+	}
+	if versions[1].Size != first.Size || !versions[1].LastModified.Equal(first.LastModified) {
+		t.Fatalf("expected size and timestamps of the first version, got %#v", versions[1])
+	}
+	if content := download(versions[1]); content != "first version" {
+		t.Fatalf("expected to download the first version, got %q", content)
+	}
+
+	restored, err := filen.RestoreFileVersion(ctx, current, versions[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := filen.FindFile(ctx, "go/versioned.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || found.UUID != restored.UUID {
+		t.Fatalf("expected the restored version to be current, got %#v", found)
+	}
+	if content := download(found); content != "first version" {
+		t.Fatalf("expected the restored content, got %q", content)
+	}
+}
+
+// failingReaderAt fails when reading at or after failAt.
+type failingReaderAt struct {
+	r      io.ReaderAt
+	failAt int64
+}
+
+func (f *failingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= f.failAt {
+		return 0, errors.New("simulated read failure")
+	}
+	return f.r.ReadAt(p, off)
+}
+
+func TestResumeUpload(t *testing.T) {
+	ctx := context.Background()
+	content := make([]byte, 5*sdk.ChunkSize+123)
+	_, _ = rand.Read(content)
+	incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, "resumed.bin", "", time.Now(), time.Now(), baseTestDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := filen.NewUploadSession(incompleteFile, int64(len(content)))
+	_, err = filen.ResumeUpload(ctx, session, &failingReaderAt{bytes.NewReader(content), 3 * sdk.ChunkSize})
+	if err == nil {
+		t.Fatal("expected the upload to fail")
+	}
+	if remaining := session.RemainingChunks(); remaining < 3 {
+		t.Fatalf("expected at least 3 chunks to remain, got %d", remaining)
+	}
+
+	// simulate a restart
+	buffer := &bytes.Buffer{}
+	if err = session.SerializeTo(buffer); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := sdk.DeserializeUploadSessionFrom(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remaining := resumed.RemainingChunks()
+	uploadedChunks := atomic.Int32{}
+	resumed.OnProgress = func(*sdk.UploadSession) {
+		uploadedChunks.Add(1)
+	}
+	file, err := filen.ResumeUpload(ctx, resumed, bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int(uploadedChunks.Load()) != remaining {
+		t.Fatalf("expected only the %d missing chunks to be uploaded, uploaded %d", remaining, uploadedChunks.Load())
+	}
+	expectedHash := sha512.Sum512(content)
+	if file.Hash != hex.EncodeToString(expectedHash[:]) {
+		t.Fatalf("expected the hash of the whole file, got %s", file.Hash)
+	}
+
+	found, err := filen.FindFile(ctx, "go/resumed.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(file, found) {
+		t.Fatalf("Uploaded \n%#v\n and found \n%#v\n file did not match", file, found)
+	}
+	downloaded, err := io.ReadAll(filen.GetDownloadReader(ctx, found))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, content) {
+		t.Fatal("downloaded content did not match")
+	}
+}
+
+func TestUploadLocalFile(t *testing.T) {
+	ctx := context.Background()
+	dir, err := filen.CreateDirectory(ctx, baseTestDir, "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"empty.txt", "large_sample-3mb.txt"} {
+		t.Run(name, func(t *testing.T) {
+			localPath := filepath.Join("test_files", name)
+			content, err := os.ReadFile(localPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			file, err := filen.UploadLocalFile(ctx, localPath, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedHash := sha512.Sum512(content)
+			if file.Name != name || file.Size != len(content) || file.Hash != hex.EncodeToString(expectedHash[:]) {
+				t.Fatalf("unexpected uploaded file %#v", file)
+			}
+
+			found, err := filen.FindFile(ctx, path.Join("go/local", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(file, found) {
+				t.Fatalf("Uploaded \n%#v\n and found \n%#v\n file did not match", file, found)
+			}
+			downloaded, err := io.ReadAll(filen.GetDownloadReader(ctx, found))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(downloaded, content) {
+				t.Fatal("downloaded content did not match")
+			}
+		})
+	}
+}
+
+func TestProgress(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []sdk.Progress
+	)
+	ctx := sdk.WithProgress(context.Background(), func(progress sdk.Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, progress)
+	})
+	lastEvent := func() sdk.Progress {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(events) == 0 {
+			t.Fatal("no progress reported")
+		}
+		last := events[len(events)-1]
+		events = nil
+		return last
+	}
+
+	content := make([]byte, 3*sdk.ChunkSize+10)
+	_, _ = rand.Read(content)
+	expectedChunks := 4
+
+	incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, "progress.bin", "", time.Now(), time.Now(), baseTestDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := filen.UploadFile(ctx, incompleteFile, bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := lastEvent(); !last.Upload || last.UUID != file.UUID || last.Bytes != int64(len(content)) ||
+		last.Chunks != expectedChunks || last.TotalBytes != -1 || last.Throughput <= 0 {
+		t.Fatalf("unexpected progress for UploadFile: %#v", last)
+	}
+
+	incompleteFile, err = types.NewIncompleteFile(filen.AuthVersion, "progress-at.bin", "", time.Now(), time.Now(), baseTestDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = filen.UploadFromReaderAt(ctx, incompleteFile, bytes.NewReader(content), int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+	if last := lastEvent(); last.Bytes != int64(len(content)) || last.TotalBytes != int64(len(content)) ||
+		last.Chunks != expectedChunks || last.TotalChunks != expectedChunks {
+		t.Fatalf("unexpected progress for UploadFromReaderAt: %#v", last)
+	}
+
+	if err = filen.DownloadToPath(ctx, file, "downloaded/progress.bin"); err != nil {
+		t.Fatal(err)
+	}
+	if last := lastEvent(); last.Upload || last.Bytes != int64(len(content)) || last.TotalBytes != int64(len(content)) ||
+		last.Chunks != expectedChunks || last.TotalChunks != expectedChunks {
+		t.Fatalf("unexpected progress for DownloadToPath: %#v", last)
+	}
+
+	reader := filen.GetDownloadReaderWithOffset(ctx, file, sdk.ChunkSize+5, 2*sdk.ChunkSize+5)
+	if _, err = io.ReadAll(reader); err != nil {
+		t.Fatal(err)
+	}
+	_ = reader.Close()
+	if last := lastEvent(); last.Chunks != 2 || last.TotalChunks != 2 || last.TotalBytes != 2*sdk.ChunkSize {
+		t.Fatalf("unexpected progress for GetDownloadReaderWithOffset: %#v", last)
+	}
+}
+
+func TestTransferOptions(t *testing.T) {
+	ctx := context.Background()
+	previous := filen.TransferOptions()
+	defer filen.SetTransferOptions(previous)
+	filen.SetTransferOptions(sdk.TransferOptions{UploadParallelism: 4, MemoryBudget: 2 * sdk.ChunkSize})
+
+	content := make([]byte, 3*sdk.ChunkSize+10)
+	_, _ = rand.Read(content)
+
+	// the per-call override leaves less memory than workers, so workers have to wait for each other
+	uploadCtx := sdk.WithTransferOptions(ctx, sdk.TransferOptions{UploadParallelism: 8})
+	incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, "transfer-options.bin", "", time.Now(), time.Now(), baseTestDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := filen.UploadFromReaderAt(uploadCtx, incompleteFile, bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	incompleteFile, err = types.NewIncompleteFile(filen.AuthVersion, "transfer-options-stream.bin", "", time.Now(), time.Now(), baseTestDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = filen.UploadFile(uploadCtx, incompleteFile, bytes.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first reader uses the whole budget, so another one has to wait until it is closed
+	reader := filen.GetDownloadReader(ctx, file)
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err = io.ReadAll(filen.GetDownloadReader(waitCtx, file)); err == nil {
+		t.Fatal("expected the second reader to wait for memory")
+	}
+	downloaded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, content) {
+		t.Fatal("downloaded content did not match")
+	}
+
+	reader = filen.GetDownloadReader(sdk.WithTransferOptions(ctx, sdk.TransferOptions{DownloadReadAhead: 1}), file)
+	downloaded, err = io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, content) {
+		t.Fatal("downloaded content did not match")
+	}
+}
+
+func TestUploadWriter(t *testing.T) {
+	ctx := context.Background()
+	content := make([]byte, 2*sdk.ChunkSize+100)
+	_, _ = rand.Read(content)
+
+	incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, "written.bin", "", time.Now(), time.Now(), baseTestDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := filen.NewUploadWriter(ctx, incompleteFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// small writes, so that chunks are filled by several of them
+	if _, err = io.CopyBuffer(w, iotest.HalfReader(bytes.NewReader(content)), make([]byte, 100_000)); err != nil {
+		t.Fatal(err)
+	}
+	if w.File() != nil {
+		t.Fatal("expected no file before Close")
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file := w.File()
+	if file == nil || file.Size != len(content) || file.Chunks != 3 {
+		t.Fatalf("unexpected file %#v", file)
+	}
+	found, err := filen.FindFile(ctx, "go/written.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(file, found) {
+		t.Fatalf("Uploaded \n%#v\n and found \n%#v\n file did not match", file, found)
+	}
+	downloaded, err := io.ReadAll(filen.GetDownloadReader(ctx, found))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, content) {
+		t.Fatal("downloaded content did not match")
+	}
+
+	incompleteFile, err = types.NewIncompleteFile(filen.AuthVersion, "aborted.bin", "", time.Now(), time.Now(), baseTestDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err = filen.NewUploadWriter(ctx, incompleteFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	w.Abort()
+	if _, err = w.Write(content); err == nil {
+		t.Fatal("expected Write to fail after Abort")
+	}
+	if err = w.Close(); !errors.Is(err, sdk.ErrUploadAborted) {
+		t.Fatalf("expected ErrUploadAborted, got %v", err)
+	}
+	if found, err = filen.FindFile(ctx, "go/aborted.bin"); err != nil || found != nil {
+		t.Fatalf("expected the aborted file not to exist, got %v, %v", found, err)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	var fetched atomic.Int32
+	ctx := sdk.WithProgress(context.Background(), func(sdk.Progress) { fetched.Add(1) })
+	content := make([]byte, 3*sdk.ChunkSize+10)
+	_, _ = rand.Read(content)
+	incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, "random-access.bin", "", time.Now(), time.Now(), baseTestDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := filen.UploadFromReaderAt(context.Background(), incompleteFile, bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// random access only fetches the chunks which are read
+	reader, err := filen.OpenFile(ctx, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, offset := range []int64{3*sdk.ChunkSize + 2, 5, 3 * sdk.ChunkSize} {
+		buf := make([]byte, 8)
+		if _, err = reader.ReadAt(buf, offset); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf, content[offset:offset+8]) {
+			t.Fatalf("content at %d did not match", offset)
+		}
+	}
+	if n := fetched.Load(); n != 2 {
+		t.Fatalf("expected 2 chunks to be fetched, got %d", n)
+	}
+	// across a chunk boundary and past the end
+	buf := make([]byte, 20)
+	if n, err := reader.ReadAt(buf, sdk.ChunkSize-10); err != nil || !bytes.Equal(buf[:n], content[sdk.ChunkSize-10:sdk.ChunkSize+10]) {
+		t.Fatalf("read across chunks failed: %d, %v", n, err)
+	}
+	if n, err := reader.ReadAt(buf, int64(len(content)-5)); err != io.EOF || n != 5 || !bytes.Equal(buf[:n], content[len(content)-5:]) {
+		t.Fatalf("expected a short read with io.EOF at the end, got %d, %v", n, err)
+	}
+	if _, err = reader.Seek(-10, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	if rest, err := io.ReadAll(reader); err != nil || !bytes.Equal(rest, content[len(content)-10:]) {
+		t.Fatalf("read after seek failed: %v", err)
+	}
+	// the file was not read in order, so the hash cannot be checked
+	if err = reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// sequential reads fetch every chunk once and verify the hash
+	var fetchedSequential atomic.Int32
+	sequentialCtx := sdk.WithProgress(context.Background(), func(sdk.Progress) { fetchedSequential.Add(1) })
+	reader, err = filen.OpenFile(sequentialCtx, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloaded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, content) {
+		t.Fatal("downloaded content did not match")
+	}
+	if n := fetchedSequential.Load(); n != int32(file.Chunks) {
+		t.Fatalf("expected %d chunks to be fetched, got %d", file.Chunks, n)
+	}
+	if err = reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// concurrent random reads share a cache of two chunks, and closing returns its memory
+	previous := filen.TransferOptions()
+	defer filen.SetTransferOptions(previous)
+	filen.SetTransferOptions(sdk.TransferOptions{MemoryBudget: 2 * sdk.ChunkSize})
+	reader, err = filen.OpenFile(context.Background(), file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	readErrs := make(chan error, 16)
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			offset := int64((i*7)%file.Chunks)*sdk.ChunkSize + int64(i%2)
+			buf := make([]byte, 8)
+			if _, err := reader.ReadAt(buf, offset); err != nil {
+				readErrs <- err
+			} else if !bytes.Equal(buf, content[offset:offset+8]) {
+				readErrs <- fmt.Errorf("content at %d did not match", offset)
+			}
+		}()
+	}
+	wg.Wait()
+	close(readErrs)
+	for err := range readErrs {
+		t.Fatal(err)
+	}
+	if err = reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reader, err = filen.OpenFile(waitCtx, file)
+	if err != nil {
+		t.Fatalf("expected the closed reader to release its memory: %v", err)
+	}
+	if err = reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	filen.SetTransferOptions(previous)
+
+	corrupted := *file
+	corrupted.Hash = strings.Repeat("0", 128)
+	reader, err = filen.OpenFile(ctx, &corrupted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = io.Copy(io.Discard, reader); err != nil {
+		t.Fatal(err)
+	}
+	if err = reader.Close(); err == nil {
+		t.Fatal("expected a hash mismatch")
+	}
+}
+
+func TestUploadDirectory(t *testing.T) {
+	ctx := context.Background()
+	local := t.TempDir()
+	large := make([]byte, sdk.ChunkSize+100)
+	_, _ = rand.Read(large)
+	files := map[string][]byte{
+		"a.txt":                   []byte("a"),
+		"sub/b.bin":               large,
+		"sub/deep/c.txt":          []byte("c"),
+		"build.log":               []byte("excluded by name"),
+		"node_modules/x/index.js": []byte("excluded directory"),
+	}
+	modTime := time.Date(2020, 5, 17, 12, 30, 0, 0, time.UTC)
+	for name, content := range files {
+		p := filepath.Join(local, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(local, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// links to files are followed, links to directories and dangling links are skipped
+	expected := []string{"a.txt", "sub/b.bin", "sub/deep/c.txt"}
+	if err := os.Symlink("a.txt", filepath.Join(local, "link.txt")); err == nil {
+		files["link.txt"] = files["a.txt"]
+		expected = []string{"a.txt", "link.txt", "sub/b.bin", "sub/deep/c.txt"}
+		if err = os.Symlink("sub", filepath.Join(local, "linked-dir")); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.Symlink("missing.txt", filepath.Join(local, "dangling.txt")); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		t.Logf("not testing symbolic links: %v", err)
+	}
+
+	target, err := filen.CreateDirectory(ctx, baseTestDir, "upload-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := filen.UploadDirectory(ctx, local, target, sdk.UploadDirectoryOptions{
+		Exclude:     []string{"*.log", "node_modules"},
+		Parallelism: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploaded := make([]string, 0)
+	for _, result := range results {
+		if result.Err != nil || result.File == nil {
+			t.Fatalf("unexpected result %#v", result)
+		}
+		uploaded = append(uploaded, result.Path)
+	}
+	if !reflect.DeepEqual(uploaded, expected) {
+		t.Fatalf("expected uploads %v, got %v", expected, uploaded)
+	}
+	for _, name := range uploaded {
+		found, err := filen.FindFile(ctx, "go/upload-dir/"+name)
+		if err != nil || found == nil {
+			t.Fatalf("file %s not found: %v", name, err)
+		}
+		if !found.LastModified.Equal(modTime) {
+			t.Fatalf("expected %s to be modified at %s, got %s", name, modTime, found.LastModified)
+		}
+		downloaded, err := io.ReadAll(filen.GetDownloadReader(ctx, found))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(downloaded, files[name]) {
+			t.Fatalf("content of %s did not match", name)
+		}
+	}
+	if dir, err := filen.FindDirectory(ctx, "go/upload-dir/empty"); err != nil || dir == nil {
+		t.Fatalf("expected the empty directory to be created: %v", err)
+	}
+	if dir, err := filen.FindDirectory(ctx, "go/upload-dir/node_modules"); err != nil || dir != nil {
+		t.Fatalf("expected the excluded directory to be skipped: %v", err)
+	}
+	if item, err := filen.FindItem(ctx, "go/upload-dir/linked-dir"); err != nil || item != nil {
+		t.Fatalf("expected the link to a directory to be skipped: %v", err)
+	}
+
+	// from an fs.FS, into the existing directories
+	fsys := fstest.MapFS{
+		"root/sub/d.txt": {Data: []byte("d"), ModTime: modTime},
+		"root/e.bin":     {Data: []byte("e"), ModTime: modTime},
+	}
+	results, err = filen.UploadDirectory(ctx, "root", target, sdk.UploadDirectoryOptions{FS: fsys, Include: []string{"*.txt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Path != "sub/d.txt" || results[0].File == nil {
+		t.Fatalf("unexpected results %#v", results)
+	}
+	if found, err := filen.FindFile(ctx, "go/upload-dir/sub/d.txt"); err != nil || found == nil || !found.LastModified.Equal(modTime) {
+		t.Fatalf("expected sub/d.txt to be uploaded next to b.bin: %#v, %v", found, err)
+	}
+
+	if _, err = filen.UploadDirectory(ctx, local, target, sdk.UploadDirectoryOptions{Include: []string{"["}}); err == nil {
+		t.Fatal("expected an invalid pattern to be rejected")
+	}
+}
+
+func TestDownloadDirectory(t *testing.T) {
+	ctx := context.Background()
+	large := make([]byte, sdk.ChunkSize+100)
+	_, _ = rand.Read(large)
+	files := map[string][]byte{
+		"a.txt":          []byte("a"),
+		"empty.txt":      {},
+		"sub/b.bin":      large,
+		"sub/deep/c.txt": []byte("c"),
+		"skip/d.txt":     []byte("d"),
+	}
+	modTime := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: content, ModTime: modTime}
+	}
+	fsys["empty-dir"] = &fstest.MapFile{Mode: fs.ModeDir}
+	remote, err := filen.CreateDirectory(ctx, baseTestDir, "download-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = filen.UploadDirectory(ctx, ".", remote, sdk.UploadDirectoryOptions{FS: fsys}); err != nil {
+		t.Fatal(err)
+	}
+
+	local := t.TempDir()
+	results, err := filen.DownloadDirectory(ctx, remote, local, sdk.DownloadDirectoryOptions{Exclude: []string{"skip"}, Parallelism: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloaded := make([]string, 0)
+	for _, result := range results {
+		if result.Err != nil || result.Skipped {
+			t.Fatalf("unexpected result %#v", result)
+		}
+		downloaded = append(downloaded, result.Path)
+		p := filepath.Join(local, filepath.FromSlash(result.Path))
+		content, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(content, files[result.Path]) {
+			t.Fatalf("content of %s did not match", result.Path)
+		}
+		stat, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !stat.ModTime().Equal(modTime) {
+			t.Fatalf("expected %s to be modified at %s, got %s", result.Path, modTime, stat.ModTime())
+		}
+	}
+	if expected := []string{"a.txt", "empty.txt", "sub/b.bin", "sub/deep/c.txt"}; !reflect.DeepEqual(downloaded, expected) {
+		t.Fatalf("expected downloads %v, got %v", expected, downloaded)
+	}
+	if stat, err := os.Stat(filepath.Join(local, "empty-dir")); err != nil || !stat.IsDir() {
+		t.Fatalf("expected the empty directory to be created: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(local, "skip")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the excluded directory not to be created: %v", err)
+	}
+	entries, err := os.ReadDir(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected no temporary files to be left, got %v", entries)
+	}
+
+	// a local change which keeps the size is only detected by the hash
+	if err = os.WriteFile(filepath.Join(local, "a.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	skipped := func(mode sdk.SkipMode) []string {
+		results, err := filen.DownloadDirectory(ctx, remote, local, sdk.DownloadDirectoryOptions{Include: []string{"*.txt", "*.bin"}, Exclude: []string{"skip"}, Skip: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths := make([]string, 0)
+		for _, result := range results {
+			if result.Skipped {
+				paths = append(paths, result.Path)
+			}
+		}
+		return paths
+	}
+	if paths := skipped(sdk.SkipSameSize); len(paths) != 4 {
+		t.Fatalf("expected all files to be skipped by size, got %v", paths)
+	}
+	if paths := skipped(sdk.SkipSameHash); !reflect.DeepEqual(paths, []string{"empty.txt", "sub/b.bin", "sub/deep/c.txt"}) {
+		t.Fatalf("expected the changed file to be downloaded, skipped %v", paths)
+	}
+	if content, err := os.ReadFile(filepath.Join(local, "a.txt")); err != nil || string(content) != "a" {
+		t.Fatalf("expected the changed file to be restored, got %q, %v", content, err)
+	}
+
+	// names which cannot be used locally only matter if they are not excluded
+	unusable := fstest.MapFS{
+		`bad\name.tmp`:  {Data: []byte("f"), ModTime: modTime},
+		`bad\dir/e.txt`: {Data: []byte("e"), ModTime: modTime},
+	}
+	if _, err = filen.UploadDirectory(ctx, ".", remote, sdk.UploadDirectoryOptions{FS: unusable}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = filen.DownloadDirectory(ctx, remote, local, sdk.DownloadDirectoryOptions{Exclude: []string{"skip", "*.tmp", "bad?dir"}}); err != nil {
+		t.Fatalf("expected excluded unusable names to be ignored, got %v", err)
+	}
+	results, err = filen.DownloadDirectory(ctx, remote, local, sdk.DownloadDirectoryOptions{Exclude: []string{"skip", "bad?dir"}})
+	if err == nil || len(results) == 0 {
+		t.Fatal("expected the unusable file name to fail")
+	}
+}
+
+func TestReadDirectoryTree(t *testing.T) {
+	ctx := context.Background()
+	modTime := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"b.txt":         {Data: []byte("b"), ModTime: modTime},
+		"a/c.txt":       {Data: []byte("c"), ModTime: modTime},
+		"a/d/e.txt":     {Data: []byte("e"), ModTime: modTime},
+		"a/d/f/g.txt":   {Data: []byte("g"), ModTime: modTime},
+		"h/i.txt":       {Data: []byte("i"), ModTime: modTime},
+		"empty-dir":     {Mode: fs.ModeDir},
+		"h/j/k/l/m.txt": {Data: []byte("m"), ModTime: modTime},
+	}
+	remote, err := filen.CreateDirectory(ctx, baseTestDir, "tree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = filen.UploadDirectory(ctx, ".", remote, sdk.UploadDirectoryOptions{FS: fsys}); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := filen.ReadDirectoryTree(ctx, remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Root.UUID() != remote.UUID || tree.Root.Path != "." {
+		t.Fatalf("unexpected root %#v", tree.Root)
+	}
+	paths := make([]string, 0)
+	for node := range tree.All() {
+		paths = append(paths, node.Path)
+	}
+	expected := []string{".", "a", "a/c.txt", "a/d", "a/d/e.txt", "a/d/f", "a/d/f/g.txt", "b.txt", "empty-dir",
+		"h", "h/i.txt", "h/j", "h/j/k", "h/j/k/l", "h/j/k/l/m.txt"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("expected paths %v, got %v", expected, paths)
+	}
+
+	node := tree.Lookup("a/d/f/g.txt")
+	if node == nil || node.IsDir() || node.Parent.Path != "a/d/f" {
+		t.Fatalf("unexpected node %#v", node)
+	}
+	found, err := filen.FindFile(ctx, "go/tree/a/d/f/g.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.File.UUID != found.UUID || node.File.Size != 1 || node.File.Hash != found.Hash || !node.File.LastModified.Equal(modTime) {
+		t.Fatalf("tree file \n%#v\n did not match \n%#v", node.File, found)
+	}
+	if dir := tree.Lookup("/h/j/"); dir == nil || !dir.IsDir() || dir.Name() != "j" || len(dir.Children) != 1 {
+		t.Fatalf("unexpected directory node %#v", dir)
+	}
+	if tree.Lookup("") != tree.Root || tree.Lookup("a/missing") != nil || tree.Lookup("b.txt/x") != nil {
+		t.Fatal("unexpected lookup results")
+	}
+
+	// skipping directories and stopping early
+	visited := make([]string, 0)
+	err = tree.Walk(func(node *sdk.TreeNode) error {
+		visited = append(visited, node.Path)
+		switch node.Path {
+		case "a/d":
+			return fs.SkipDir
+		case "h/i.txt":
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{".", "a", "a/c.txt", "a/d", "b.txt", "empty-dir", "h", "h/i.txt"}; !reflect.DeepEqual(visited, expected) {
+		t.Fatalf("expected visits %v, got %v", expected, visited)
+	}
+}
+
+// countingTransport counts the requests sent through it.
+type countingTransport struct {
+	http.RoundTripper
+	requests atomic.Int64
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return t.RoundTripper.RoundTrip(req)
+}
+
+func TestPathCache(t *testing.T) {
+	if fakeServer == nil {
+		t.Skip("counting requests requires the fake server")
+	}
+	ctx := context.Background()
+	clientOptions := fakeServer.ClientOptions()
+	transport := &countingTransport{RoundTripper: clientOptions.Transport}
+	clientOptions.Transport = transport
+	cached, err := sdk.NewWithOptions(ctx, email, password, sdk.Options{Client: clientOptions, APIKey: filen.GetAPIKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached.EnablePathCache(0)
+	// requests returns the number of requests sent since it was last called
+	requests := func() int64 {
+		return transport.requests.Swap(0)
+	}
+
+	b, err := cached.FindDirectoryOrCreate(ctx, "go/path-cache/a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	incompleteFile, err := types.NewIncompleteFile(cached.AuthVersion, "file.txt", "", time.Now(), time.Now(), b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cached.UploadFile(ctx, incompleteFile, strings.NewReader("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	// created directories are cached, so only the parent of the file is listed
+	requests()
+	file, err := cached.FindFile(ctx, "go/path-cache/a/b/file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file == nil || file.Name != "file.txt" {
+		t.Fatalf("unexpected file %#v", file)
+	}
+	if n := requests(); n != 1 {
+		t.Fatalf("expected 1 request, got %d", n)
+	}
+	dir, err := cached.FindDirectory(ctx, "/go/path-cache/a/b/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir == nil || dir.GetUUID() != b.GetUUID() {
+		t.Fatalf("unexpected directory %#v", dir)
+	}
+	if n := requests(); n != 1 {
+		t.Fatalf("expected 1 request, got %d", n)
+	}
+
+	// a file wins over a cached directory with the same name
+	requests()
+	same, err := cached.CreateDirectory(ctx, b, "same")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := requests(); n != 1 {
+		t.Fatalf("expected creating a directory to take 1 request, got %d", n)
+	}
+	// creating an existing directory fetches it, and caches it
+	if existing, err := cached.CreateDirectory(ctx, b, "SAME"); err != nil || existing.UUID != same.UUID || existing.Name != "same" {
+		t.Fatalf("expected the existing directory, got %#v, %v", existing, err)
+	}
+	if n := requests(); n != 2 {
+		t.Fatalf("expected creating an existing directory to take 2 requests, got %d", n)
+	}
+	incompleteFile, err = types.NewIncompleteFile(cached.AuthVersion, "same", "", time.Now(), time.Now(), b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cached.UploadFile(ctx, incompleteFile, strings.NewReader("same")); err != nil {
+		t.Fatal(err)
+	}
+	if file, err = cached.FindFile(ctx, "go/path-cache/a/b/same"); err != nil || file == nil {
+		t.Fatalf("expected the file, got %#v, %v", file, err)
+	}
+
+	// renaming updates the cache
+	a, err := cached.FindDirectory(ctx, "go/path-cache/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cached.RenameDirectory(ctx, a.(*types.Directory), "c"); err != nil {
+		t.Fatal(err)
+	}
+	if dir, err = cached.FindDirectory(ctx, "go/path-cache/a/b"); err != nil || dir != nil {
+		t.Fatalf("expected renamed directory to be gone, got %#v, %v", dir, err)
+	}
+	requests()
+	if dir, err = cached.FindDirectory(ctx, "go/path-cache/c/b"); err != nil || dir == nil || dir.GetUUID() != b.GetUUID() {
+		t.Fatalf("expected renamed directory, got %#v, %v", dir, err)
+	}
+	if n := requests(); n != 1 {
+		t.Fatalf("expected 1 request, got %d", n)
+	}
+
+	// changes made elsewhere need explicit invalidation
+	if err = filen.TrashDirectory(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	if file, err = cached.FindFile(ctx, "go/path-cache/c/b/file.txt"); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("expected listing the stale cached directory to fail, got %#v, %v", file, err)
+	}
+	cached.InvalidatePath("go/path-cache/c")
+	if file, err = cached.FindFile(ctx, "go/path-cache/c/b/file.txt"); err != nil || file != nil {
+		t.Fatalf("expected the file in the trashed directory to be gone, got %#v, %v", file, err)
+	}
+	if dir, err = cached.FindDirectory(ctx, "go/path-cache/c/b"); err != nil || dir != nil {
+		t.Fatalf("expected trashed directory to be gone, got %#v, %v", dir, err)
+	}
+
+	// trashing updates the cache
+	c, err := cached.FindDirectory(ctx, "go/path-cache/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cached.TrashDirectory(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if dir, err = cached.FindDirectoryOrCreate(ctx, "go/path-cache/c"); err != nil || dir.GetUUID() == c.GetUUID() {
+		t.Fatalf("expected a new directory, got %#v, %v", dir, err)
+	}
+
+	// expired entries are looked up again
+	cached.EnablePathCache(time.Nanosecond)
+	requests()
+	for range 2 {
+		if _, err = cached.FindDirectory(ctx, "go/path-cache/c"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := requests(); n < 6 {
+		t.Fatalf("expected both lookups to list all directories, got %d requests", n)
+	}
+}
+
+func TestGetByUUID(t *testing.T) {
+	ctx := context.Background()
+	dir, err := filen.FindDirectoryOrCreate(ctx, "go/get-by-uuid/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := make([]byte, sdk.ChunkSize+100)
+	_, _ = rand.Read(contents)
+	incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, "file.bin", "", time.Now(), time.Now(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploaded, err := filen.UploadFile(ctx, incompleteFile, bytes.NewReader(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := filen.GetFile(ctx, uploaded.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Name != "file.bin" || file.ParentUUID != dir.GetUUID() || file.Size != len(contents) || file.Chunks != 2 || file.Hash != uploaded.Hash {
+		t.Fatalf("unexpected file %#v", file)
+	}
+	downloaded, err := io.ReadAll(filen.GetDownloadReader(ctx, file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(contents, downloaded) {
+		t.Fatal("downloaded contents did not match")
+	}
+
+	directory, err := filen.GetDirectory(ctx, dir.GetUUID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if directory.Name != "a" || directory.ParentUUID != dir.GetParent() {
+		t.Fatalf("unexpected directory %#v", directory)
+	}
+
+	for item, expected := range map[types.FileSystemObject]string{
+		file:              "go/get-by-uuid/a/file.bin",
+		directory:         "go/get-by-uuid/a",
+		&filen.BaseFolder: "",
+	} {
+		p, err := filen.GetPath(ctx, item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != expected {
+			t.Fatalf("expected path %q, got %q", expected, p)
+		}
+	}
+
+	// trashed items can still be fetched
+	if err = filen.TrashFile(ctx, *file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = filen.GetFile(ctx, file.UUID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = filen.GetFile(ctx, uuid.NewString()); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if _, err = filen.GetDirectory(ctx, uuid.NewString()); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestConflictPolicy(t *testing.T) {
+	ctx := context.Background()
+	dir, err := filen.FindDirectoryOrCreate(ctx, "go/conflicts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	upload := func(ctx context.Context, name string, content string) (*types.File, error) {
+		incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, name, "", time.Now(), time.Now(), dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return filen.UploadFile(ctx, incompleteFile, strings.NewReader(content))
+	}
+	original, err := upload(ctx, "File.txt", "original")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]bool{"file.TXT": true, "File.txt": true, "other.txt": false} {
+		exists, err := filen.FileExists(ctx, dir, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists != expected {
+			t.Fatalf("expected FileExists(%q) to be %v", name, expected)
+		}
+	}
+	if exists, err := filen.DirectoryExists(ctx, dir, "file.txt"); err != nil || exists {
+		t.Fatalf("expected no directory, got %v, %v", exists, err)
+	}
+
+	if _, err = upload(sdk.WithConflictPolicy(ctx, sdk.ConflictFail), "file.txt", "fail"); !errors.Is(err, sdk.ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+	for _, expected := range []string{"file (1).txt", "file (2).txt"} {
+		renamed, err := upload(sdk.WithConflictPolicy(ctx, sdk.ConflictRename), "file.txt", "renamed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if renamed.Name != expected {
+			t.Fatalf("expected name %q, got %q", expected, renamed.Name)
+		}
+	}
+	if _, err = upload(ctx, ".hidden", "hidden"); err != nil {
+		t.Fatal(err)
+	}
+	if renamed, err := upload(sdk.WithConflictPolicy(ctx, sdk.ConflictRename), ".hidden", "renamed"); err != nil || renamed.Name != ".hidden (1)" {
+		t.Fatalf("expected renamed hidden file, got %#v, %v", renamed, err)
+	}
+
+	skipped, err := upload(sdk.WithConflictPolicy(ctx, sdk.ConflictSkip), "file.txt", "skipped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped.UUID != original.UUID || skipped.Hash != original.Hash {
+		t.Fatalf("expected the existing file, got %#v", skipped)
+	}
+	incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, "file.txt", "", time.Now(), time.Now(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := filen.NewUploadWriter(sdk.WithConflictPolicy(ctx, sdk.ConflictSkip), incompleteFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write(make([]byte, sdk.ChunkSize+1)); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if w.File().UUID != original.UUID {
+		t.Fatalf("expected the existing file, got %#v", w.File())
+	}
+
+	// the default uploads a new version
+	overwritten, err := upload(ctx, "file.txt", "overwritten")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := filen.ReadDirectory(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0)
+	for _, file := range files {
+		names = append(names, file.Name)
+		if strings.EqualFold(file.Name, "file.txt") && file.UUID != overwritten.UUID {
+			t.Fatalf("expected the new version, got %#v", file)
+		}
+	}
+	slices.Sort(names)
+	if expected := []string{".hidden", ".hidden (1)", "file (1).txt", "file (2).txt", "file.txt"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected files %v, got %v", expected, names)
+	}
+
+	// directories
+	sub, err := filen.CreateDirectory(ctx, dir, "Sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := filen.DirectoryExists(ctx, dir, "sub"); err != nil || !exists {
+		t.Fatalf("expected directory to exist, got %v, %v", exists, err)
+	}
+	if _, err = filen.CreateDirectory(sdk.WithConflictPolicy(ctx, sdk.ConflictFail), dir, "sub"); !errors.Is(err, sdk.ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+	if renamed, err := filen.CreateDirectory(sdk.WithConflictPolicy(ctx, sdk.ConflictRename), dir, "sub"); err != nil || renamed.Name != "sub (1)" || renamed.UUID == sub.UUID {
+		t.Fatalf("expected renamed directory, got %#v, %v", renamed, err)
+	}
+	for _, policy := range []sdk.ConflictPolicy{sdk.ConflictOverwrite, sdk.ConflictSkip} {
+		existing, err := filen.CreateDirectory(sdk.WithConflictPolicy(ctx, policy), dir, "sub")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if existing.UUID != sub.UUID || existing.Name != "Sub" || !existing.Created.Equal(sub.Created) {
+			t.Fatalf("expected the existing directory with policy %d, got %#v", policy, existing)
+		}
+	}
+}
+
+func TestFavorites(t *testing.T) {
+	ctx := context.Background()
+	dir, err := filen.FindDirectoryOrCreate(ctx, "go/favorites")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := filen.CreateDirectory(ctx, dir, "sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	incompleteFile, err := types.NewIncompleteFile(filen.AuthVersion, "favorite.txt", "", time.Now(), time.Now(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := filen.UploadFile(ctx, incompleteFile, strings.NewReader("favorite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// listFavorites returns which of file and sub are listed as favorites
+	listFavorites := func() (bool, bool) {
+		files, directories, err := filen.ListFavorites(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileListed, subListed := false, false
+		for _, f := range files {
+			fileListed = fileListed || f.UUID == file.UUID
+		}
+		for _, d := range directories {
+			subListed = subListed || d.UUID == sub.UUID
+		}
+		return fileListed, subListed
+	}
+	// readItems returns file and sub as listed in dir
+	readItems := func() (*types.File, *types.Directory) {
+		files, directories, err := filen.ReadDirectory(ctx, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 1 || len(directories) != 1 {
+			t.Fatalf("expected one file and one directory, got %d and %d", len(files), len(directories))
+		}
+		return files[0], directories[0]
+	}
+
+	if err = filen.SetFavorite(ctx, file, true); err != nil {
+		t.Fatal(err)
+	}
+	if err = filen.SetFavorite(ctx, sub, true); err != nil {
+		t.Fatal(err)
+	}
+	if fileListed, subListed := listFavorites(); !fileListed || !subListed {
+		t.Fatalf("expected both items to be favorites, got %v and %v", fileListed, subListed)
+	}
+	if f, d := readItems(); !f.Favorited || !d.Favorited {
+		t.Fatalf("expected both items to be favorites, got %#v and %#v", f, d)
+	}
+
+	if err = filen.SetDirectoryColor(ctx, sub, types.DirColorBlue); err != nil {
+		t.Fatal(err)
+	}
+	if _, d := readItems(); d.Color != types.DirColorBlue {
+		t.Fatalf("expected color %q, got %q", types.DirColorBlue, d.Color)
+	}
+	if err = filen.SetDirectoryColor(ctx, sub, types.DirColorDefault); err != nil {
+		t.Fatal(err)
+	}
+	if _, d := readItems(); d.Color != types.DirColorDefault {
+		t.Fatalf("expected default color, got %q", d.Color)
+	}
+
+	if err = filen.SetFavorite(ctx, sub, false); err != nil {
+		t.Fatal(err)
+	}
+	if f, d := readItems(); !f.Favorited || d.Favorited {
+		t.Fatalf("expected only the file to be a favorite, got %#v and %#v", f, d)
+	}
+	if err = filen.TrashFile(ctx, *file); err != nil {
+		t.Fatal(err)
+	}
+	if fileListed, subListed := listFavorites(); fileListed || subListed {
+		t.Fatalf("expected no favorites, got %v and %v", fileListed, subListed)
+	}
+}
+
+func TestDirectorySize(t *testing.T) {
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"a.bin":       {Data: make([]byte, sdk.ChunkSize+10)},
+		"b/c.txt":     {Data: []byte("c")},
+		"b/d/e.txt":   {Data: []byte("eeeee")},
+		"b/d/f/empty": {Data: []byte{}},
+		"g":           {Mode: fs.ModeDir},
+	}
+	dir, err := filen.CreateDirectory(ctx, baseTestDir, "size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = filen.UploadDirectory(ctx, ".", dir, sdk.UploadDirectoryOptions{FS: fsys}); err != nil {
+		t.Fatal(err)
+	}
+	expected := sdk.DirectoryStats{Size: sdk.ChunkSize + 16, Files: 4, Directories: 4}
+
+	stats, err := filen.DirectorySize(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != expected {
+		t.Fatalf("expected %+v, got %+v", expected, stats)
+	}
+	tree, err := filen.ReadDirectoryTree(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats = tree.Stats(); stats != expected {
+		t.Fatalf("expected tree stats %+v, got %+v", expected, stats)
+	}
+
+	missing := *dir
+	missing.UUID = uuid.New().String()
+	if _, err = filen.DirectorySize(ctx, &missing); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a missing directory, got %v", err)
+	}
+
+	if fakeServer != nil {
+		// falls back to the directory tree if the server fails to compute the size
+		clientOptions := fakeServer.ClientOptions()
+		clientOptions.Retry = client.RetryPolicy{MaxAttempts: 1}
+		nonRetrying, err := sdk.NewWithOptions(ctx, email, password, sdk.Options{Client: clientOptions, APIKey: filen.GetAPIKey()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fakeServer.FailRequests(1, http.StatusInternalServerError)
+		stats, err = nonRetrying.DirectorySize(ctx, dir)
+		fakeServer.FailRequests(0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats != expected {
+			t.Fatalf("expected fallback %+v, got %+v", expected, stats)
+		}
+	}
+}